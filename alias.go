@@ -0,0 +1,55 @@
+package ini
+
+// An AliasedFile wraps a File so that lookups accept other names for sections, such as the old name of a
+// section during a rename migration. Aliases are resolved at lookup time, so the stored sections stay
+// separate and are never merged.
+//
+// Aliases cannot be registered on a File directly, as a File is a plain map with no room for them, and
+// the File is a field rather than embedded so that its methods, which know nothing of aliases, are not
+// mistaken for resolving ones. Only the methods below resolve aliases; for typed values, use the Section
+// getters on the result of GetSection, e.g. af.GetSection("buffer").Int("size").
+type AliasedFile struct {
+	File    File
+	aliases map[string]string
+}
+
+// Returns an AliasedFile over f with no aliases registered. It shares f's data, so changes to either are
+// visible in both.
+func NewAliasedFile(f File) AliasedFile {
+	return AliasedFile{File: f, aliases: make(map[string]string)}
+}
+
+// Registers alias as another name for the canonical section, so lookups fall through to canonical when
+// no section named alias exists. When both sections exist, the explicitly named one is used as a whole.
+func (af AliasedFile) SectionAlias(alias, canonical string) {
+	af.aliases[alias] = canonical
+}
+
+// Returns the named section, or the section it is an alias of if it does not exist. It returns nil if
+// neither exists.
+func (af AliasedFile) GetSection(name string) Section {
+	if section, ok := af.File[name]; ok {
+		return section
+	}
+	if canonical, ok := af.aliases[name]; ok {
+		return af.File[canonical]
+	}
+	return nil
+}
+
+// Looks up a value for a key in a section, resolving aliases as GetSection does.
+func (af AliasedFile) Get(section, key string) (value string, ok bool) {
+	value, ok = af.GetSection(section)[key]
+	return
+}
+
+// Reports whether a key is present in a section, resolving aliases as GetSection does.
+func (af AliasedFile) HasKey(section, key string) bool {
+	_, ok := af.Get(section, key)
+	return ok
+}
+
+// Returns the value of a key, or def if it is missing, resolving aliases as GetSection does.
+func (af AliasedFile) GetStringOr(section, key, def string) string {
+	return af.GetSection(section).StringOr(key, def)
+}
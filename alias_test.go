@@ -0,0 +1,37 @@
+package ini
+
+import "testing"
+
+func TestSectionAlias(t *testing.T) {
+	file := File{"cache": {"size": "1M", "ways": "4"}}
+	af := NewAliasedFile(file)
+	af.SectionAlias("buffer", "cache")
+	if value, ok := af.Get("buffer", "size"); !ok || value != "1M" {
+		t.Errorf("expected the alias to resolve, got %q, %v", value, ok)
+	}
+	if section := af.GetSection("buffer"); section["size"] != "1M" {
+		t.Errorf("expected GetSection to resolve the alias, got %v", section)
+	}
+	if !af.HasKey("buffer", "size") || af.GetStringOr("buffer", "size", "0") != "1M" {
+		t.Error("expected HasKey and GetStringOr to resolve the alias")
+	}
+	if n, err := af.GetSection("buffer").Int("ways"); err != nil || n != 4 {
+		t.Errorf("expected Section getters to work on the resolved section, got %d, %v", n, err)
+	}
+	if _, ok := file["buffer"]; ok {
+		t.Error("expected aliases not to create sections")
+	}
+	if _, ok := file.Get("buffer", "size"); ok {
+		t.Error("expected the plain File not to resolve aliases")
+	}
+	file["buffer"] = Section{"ttl": "1m"}
+	if _, ok := af.Get("buffer", "size"); ok {
+		t.Error("expected an existing alias section to take precedence")
+	}
+	if af.GetStringOr("missing", "size", "0") != "0" {
+		t.Error("expected the default for a missing section")
+	}
+	if section := af.GetSection("missing"); section != nil {
+		t.Errorf("expected nil, got %v", section)
+	}
+}
@@ -0,0 +1,81 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// A Binder reads typed values from one section into variables, collecting every failure so they can be
+// reported together by Err.
+type Binder struct {
+	name    string
+	section Section
+	errs    []error
+}
+
+// Returns a Binder for the named section. A missing section binds nothing.
+func (f File) Binder(section string) *Binder {
+	return &Binder{name: section, section: f.GetSection(section)}
+}
+
+// Stores the value of key in p. Missing keys leave the target unchanged, so it can hold a default.
+func (b *Binder) String(key string, p *string) {
+	if value, ok := b.section[key]; ok {
+		*p = value
+	}
+}
+
+// Stores the int value of key in p on success.
+func (b *Binder) Int(key string, p *int) {
+	if _, ok := b.section[key]; ok {
+		n, err := b.section.Int(key)
+		if b.check(err) {
+			*p = n
+		}
+	}
+}
+
+// Stores the float64 value of key in p on success.
+func (b *Binder) Float(key string, p *float64) {
+	if _, ok := b.section[key]; ok {
+		n, err := b.section.Float(key)
+		if b.check(err) {
+			*p = n
+		}
+	}
+}
+
+// Stores the boolean value of key, read with GetBool spellings, in p on success.
+func (b *Binder) Bool(key string, p *bool) {
+	if _, ok := b.section[key]; ok {
+		v, err := b.section.Bool(key)
+		if b.check(err) {
+			*p = v
+		}
+	}
+}
+
+// Stores the time.Duration value of key in p on success.
+func (b *Binder) Duration(key string, p *time.Duration) {
+	if _, ok := b.section[key]; ok {
+		d, err := b.section.Duration(key)
+		if b.check(err) {
+			*p = d
+		}
+	}
+}
+
+// Returns every binding failure so far joined with errors.Join, or nil if there were none.
+func (b *Binder) Err() error {
+	return errors.Join(b.errs...)
+}
+
+// Records err, annotated with the section name, and reports whether it was nil.
+func (b *Binder) check(err error) bool {
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("section %q: %w", b.name, err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package ini
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBinder(t *testing.T) {
+	file := File{"server": {"port": "80", "tls": "maybe", "timeout": "5s", "ratio": "x", "host": "a"}}
+	cfg := struct {
+		Host    string
+		Port    int
+		TLS     bool
+		Timeout time.Duration
+		Ratio   float64
+		Workers int
+	}{Ratio: 1, Workers: 4}
+	b := file.Binder("server")
+	b.String("host", &cfg.Host)
+	b.Int("port", &cfg.Port)
+	b.Bool("tls", &cfg.TLS)
+	b.Duration("timeout", &cfg.Timeout)
+	b.Float("ratio", &cfg.Ratio)
+	b.Int("workers", &cfg.Workers)
+	if cfg.Host != "a" || cfg.Port != 80 || cfg.Timeout != 5*time.Second || cfg.Workers != 4 || cfg.Ratio != 1 || cfg.TLS {
+		t.Errorf("unexpected bound values %+v", cfg)
+	}
+	expect := "section \"server\": invalid bool value \"maybe\" for key \"tls\"\n" +
+		"section \"server\": invalid float value \"x\" for key \"ratio\""
+	if err := b.Err(); err == nil || err.Error() != expect {
+		t.Errorf("expected %q, got %v", expect, err)
+	}
+	if err := file.Binder("missing").Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
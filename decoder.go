@@ -0,0 +1,514 @@
+package ini
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A Decoder reads INI data from an input stream, either all at once with Decode or one entry at a time
+// with Next.
+type Decoder struct {
+	in      *bufio.Reader
+	opts    ParseOptions
+	section string
+	lineNum int
+	// Byte offsets of the end of the data read so far and of the start of the last line read.
+	offset    int64
+	lineStart int64
+	errs      ErrSyntaxList
+	done      bool
+	// State of a raw section: whether one is being read, its lines so far, and a header line that ended
+	// it and still has to be parsed.
+	inRaw      bool
+	rawLines   []string
+	pending    string
+	hasPending bool
+	// Comment lines read since the last entry returned, when collecting comments.
+	commentLines []string
+	// What decoding recorded about the input for RepeatedSections, TrackOffsets and CollectComments: the
+	// blocks of each section, the byte range of each section, and comments keyed by section and the key
+	// they precede.
+	blocks   map[string][]Section
+	ranges   map[string][2]int64
+	comments map[[2]string]string
+	// Include state: the absolute paths of the files being read, ending with this one if it was opened
+	// by name, the number of includes leading to it, and the Decoder and file of an included file
+	// currently being read.
+	chain   []string
+	depth   int
+	sub     *Decoder
+	subFile *os.File
+	// Whether the last entry returned by Next came from an included file.
+	included bool
+	// Whether the lines skipped by SkipLines and StartMarker are behind.
+	started bool
+	// The file opened by NewFileDecoder, closed by Close.
+	file *os.File
+}
+
+// Returns a new Decoder reading from r. The Decoder buffers r unless it is already a *bufio.Reader, in
+// which case it reads no further than the data it decodes.
+func NewDecoder(r io.Reader) *Decoder {
+	in, ok := r.(*bufio.Reader)
+	if !ok {
+		in = bufio.NewReader(r)
+	}
+	return &Decoder{in: in}
+}
+
+// Returns a new Decoder reading the named file with the given options, wrapped by opts.Transform if set.
+// Like LoadFileWith it resolves includes relative to the file's directory, and it gives access to what
+// decoding records, such as Decoder.SectionList, which LoadFileWith discards. The caller must call Close
+// when done with the Decoder.
+func NewFileDecoder(filename string, opts ParseOptions) (*Decoder, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = f
+	if opts.Transform != nil {
+		r = opts.Transform(r)
+	}
+	d := NewDecoder(r)
+	d.SetOptions(opts)
+	d.file = f
+	if abs, err := filepath.Abs(filename); err == nil {
+		d.chain = []string{abs}
+	}
+	return d, nil
+}
+
+// Sets the options used for the rest of the stream.
+func (d *Decoder) SetOptions(opts ParseOptions) {
+	if d.section == d.opts.DefaultSectionName {
+		d.section = opts.DefaultSectionName
+	}
+	d.opts = opts
+}
+
+// Reads the remaining INI data and returns it as a File.
+func (d *Decoder) Decode() (File, error) {
+	file := make(File)
+	err := d.decodeInto(file)
+	return file, err
+}
+
+// Returns every block of a section in the order they appeared, when decoding with RepeatedSections.
+// It returns nil for a section that was never declared or when blocks were not recorded. Like offsets
+// and comments, blocks only cover the top-level input: entries read from included files are left out.
+//
+// The blocks live on the Decoder rather than behind a File.SectionList method because a File is just a
+// map of sections: it has nowhere to hold them, and keeping them in a table keyed by File would never
+// release them.
+func (d *Decoder) SectionList(name string) []Section {
+	if blocks := d.blocks[name]; blocks != nil {
+		return append([]Section(nil), blocks...)
+	}
+	return nil
+}
+
+// Returns the byte range [start, end) a section occupied in the input, when decoding with TrackOffsets.
+// A section starts at its header line and ends where the next header starts, or at the end of the
+// input, so blank lines and comments before the next header belong to it. The default section starts at
+// offset 0. For a section declared more than once, the range of its last block is returned. ok is false
+// if no range was recorded.
+//
+// Offsets describe the input rather than the parsed sections, so there is no File.SectionRange: a File
+// is a map that may be edited, merged or built by hand and keeps no trace of where it came from.
+func (d *Decoder) SectionRange(name string) (start, end int64, ok bool) {
+	r, ok := d.ranges[name]
+	return r[0], r[1], ok
+}
+
+// Returns the comment preceding a key of a section, when decoding with CollectComments. The comment
+// markers and surrounding whitespace are removed from each line, and the lines of a multi-line comment
+// are joined with newlines. An empty key returns the trailing comments of the section. An empty string
+// is returned if there is no such comment.
+//
+// A File.Comment method is not possible without changing File itself: its sections hold only key/value
+// strings, and a comment stored there as a key would be written out and read back as a property.
+func (d *Decoder) Comment(section, key string) string {
+	return d.comments[[2]string{section, key}]
+}
+
+// Reads the next entry from the stream. A property is returned with the section it belongs to, while a
+// section header is returned with an empty key and value. At the end of the stream Next returns io.EOF,
+// or an ErrSyntaxList if errors were collected with CollectErrors.
+func (d *Decoder) Next() (section, key, value string, err error) {
+	d.commentLines = d.commentLines[:0]
+	d.included = false
+	if d.sub != nil {
+		if section, key, value, err = d.nextIncluded(); err != io.EOF {
+			return
+		}
+	}
+	for !d.done || d.hasPending {
+		var line string
+		if d.hasPending {
+			line, d.hasPending = d.pending, false
+		} else {
+			if line, err = d.read(); err != nil {
+				return
+			}
+			if !d.started {
+				if d.lineNum <= d.opts.SkipLines {
+					continue
+				}
+				if d.opts.StartMarker != "" {
+					d.started = strings.TrimSpace(line) == d.opts.StartMarker
+					continue
+				}
+				d.started = true
+			}
+			if d.inRaw {
+				if trimmed := strings.TrimSpace(line); !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+					d.rawLines = append(d.rawLines, strings.TrimRight(line, "\r\n"))
+					continue
+				}
+				d.pending, d.hasPending = line, true
+				return d.endRaw()
+			}
+		}
+		raw := line
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			// Skip blank lines
+			continue
+		}
+		if line[0] == ';' || line[0] == '#' {
+			// Skip comments
+			if d.opts.CollectComments {
+				d.commentLines = append(d.commentLines, strings.TrimSpace(line[1:]))
+			}
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "@include "); ok && d.opts.Include {
+			if err = d.include(strings.TrimSpace(name)); err != nil {
+				return "", "", "", err
+			}
+			if section, key, value, err = d.nextIncluded(); err != io.EOF {
+				return
+			}
+			continue
+		}
+		if d.opts.InlineComments {
+			line = stripInlineComment(line, d.opts.Quotes)
+		}
+
+		split := splitLine
+		if d.opts.TabDelimiter {
+			split = splitLineTab
+		}
+		if name, val, isSection, ok := split(line); ok && !isSection {
+			d.warnIndent(raw, "indented property may be meant as a continuation of the previous value")
+			key = strings.TrimSpace(name)
+			if !d.opts.CaseSensitive || d.opts.LowercaseKeys {
+				key = strings.ToLower(key)
+			}
+			if d.opts.KeyNameFunc != nil {
+				if err = d.opts.KeyNameFunc(key); err != nil {
+					return "", "", "", fmt.Errorf("line %d: %w", d.lineNum, err)
+				}
+			}
+			val = strings.TrimSpace(val)
+			if d.opts.IndentContinuation {
+				if val, err = d.continuation(val, split); err != nil {
+					return "", "", "", err
+				}
+			}
+			if d.opts.Quotes {
+				val = unquote(val)
+			} else if d.opts.StripQuotes && len(val) >= 2 && val[0] == val[len(val)-1] && (val[0] == '"' || val[0] == '\'') {
+				val = val[1 : len(val)-1]
+			}
+			if name, ok := strings.CutPrefix(val, fileDirective); ok && d.opts.FileDirective {
+				data, err := os.ReadFile(name)
+				if err != nil {
+					return "", "", "", fmt.Errorf("line %d: key %q: %w", d.lineNum, key, err)
+				}
+				val = strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+			}
+			return d.section, key, val, nil
+		} else if ok && (strings.TrimSpace(name) != "" || !d.opts.RejectEmptySection) {
+			d.warnIndent(raw, "indented section header")
+			d.section = strings.TrimSpace(name)
+			if d.opts.EscapedSectionNames {
+				d.section = unescapeSectionName(d.section)
+			}
+			if !d.opts.CaseSensitive || d.opts.LowercaseSections {
+				d.section = strings.ToLower(d.section)
+			}
+			if d.opts.RawSections && strings.HasSuffix(strings.ToLower(d.section), "|raw") {
+				d.section = strings.TrimSpace(d.section[:len(d.section)-len("|raw")])
+				d.inRaw = true
+			}
+			if d.section == "" {
+				d.section = d.opts.DefaultSectionName
+			}
+			if d.opts.SectionNameFunc != nil {
+				if err = d.opts.SectionNameFunc(d.section); err != nil {
+					return "", "", "", fmt.Errorf("line %d: %w", d.lineNum, err)
+				}
+			}
+			return d.section, "", "", nil
+		} else if err = d.invalid(line); err != nil {
+			return "", "", "", err
+		}
+	}
+	if d.inRaw {
+		return d.endRaw()
+	}
+	if !d.started && d.opts.StartMarker != "" {
+		return "", "", "", fmt.Errorf("start marker %q not found", d.opts.StartMarker)
+	}
+	if len(d.errs) > 0 {
+		errs := d.errs
+		d.errs = nil
+		return "", "", "", ErrSyntaxList(errs.Errors())
+	}
+	return "", "", "", io.EOF
+}
+
+// The prefix of values read from a file with ParseOptions.FileDirective.
+const fileDirective = "@file:"
+
+// Reads the next line, keeping track of offsets and the line number, and marks the Decoder done at the
+// end of the input.
+func (d *Decoder) read() (string, error) {
+	d.lineStart = d.offset
+	line, err := d.readLine()
+	if err == io.EOF {
+		d.done, err = true, nil
+	}
+	d.offset += int64(len(line))
+	d.lineNum++
+	return line, err
+}
+
+// Appends the indented lines following a property to its value for IndentContinuation, joined with
+// "\n". The first line that is not indented, is blank or a comment, or parses as a property or section
+// header ends the value and is kept to be parsed next.
+func (d *Decoder) continuation(val string, split func(string) (string, string, bool, bool)) (string, error) {
+	for !d.done {
+		line, err := d.read()
+		if err != nil {
+			return "", err
+		}
+		trimmed := strings.TrimSpace(line)
+		if d.opts.InlineComments {
+			trimmed = stripInlineComment(trimmed, d.opts.Quotes)
+		}
+		_, _, _, ok := split(trimmed)
+		if trimmed == "" || ok || (line[0] != ' ' && line[0] != '\t') || trimmed[0] == ';' || trimmed[0] == '#' {
+			d.pending, d.hasPending = line, true
+			break
+		}
+		val += "\n" + trimmed
+	}
+	return val, nil
+}
+
+// Reads the next line including its terminator, which may be "\n", "\r\n" or a lone "\r". At the end of
+// the input it returns the remaining data along with io.EOF, as bufio.Reader.ReadString does.
+func (d *Decoder) readLine() (string, error) {
+	var line []byte
+	for {
+		c, err := d.in.ReadByte()
+		if err != nil {
+			return string(line), err
+		}
+		line = append(line, c)
+		switch c {
+		case '\n':
+			return string(line), nil
+		case '\r':
+			if next, err := d.in.Peek(1); err == nil && next[0] == '\n' {
+				d.in.ReadByte()
+				line = append(line, '\n')
+			}
+			return string(line), nil
+		}
+	}
+}
+
+// Finishes a raw section, returning its lines as the value of RawKey without trailing blank lines.
+func (d *Decoder) endRaw() (section, key, value string, err error) {
+	lines := d.rawLines
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	d.inRaw, d.rawLines = false, nil
+	return d.section, RawKey, strings.Join(lines, "\n"), nil
+}
+
+// Replaces "\]" with "]" and "\\" with a backslash in a section name. Other backslashes are kept.
+func unescapeSectionName(name string) string {
+	if !strings.Contains(name, "\\") {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+1 < len(name) && (name[i+1] == ']' || name[i+1] == '\\') {
+			i++
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
+
+// Handles a line that cannot be parsed, returning a non-nil error if parsing should stop.
+func (d *Decoder) invalid(line string) error {
+	if d.opts.OnError != nil {
+		return d.opts.OnError(d.lineNum, line)
+	} else if d.opts.CollectErrors {
+		d.errs = append(d.errs, ErrSyntax{Line: d.lineNum, Source: line})
+		return nil
+	}
+	return ErrSyntax{Line: d.lineNum, Source: line}
+}
+
+// Removes a trailing comment, started by a ";" or "#" preceded by whitespace, from a trimmed line. With
+// quotes set, markers inside double or single quotes are skipped.
+func stripInlineComment(line string, quotes bool) string {
+	var quote byte
+	for i := 1; i < len(line); i++ {
+		switch c := line[i]; {
+		case quotes && c == '\\' && quote == '"':
+			i++
+		case quotes && quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quotes && c == quote:
+			quote = 0
+		case quote == 0 && (c == ';' || c == '#') && (line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimSpace(line[:i])
+		}
+	}
+	return line
+}
+
+// Removes double quotes wrapping a whole value and unescapes \" and \\ inside them, or removes single
+// quotes wrapping a whole value and keeps everything inside literally. Other values, including those
+// starting and ending with different quotes, are returned unchanged.
+func unquote(value string) string {
+	if len(value) < 2 || value[0] != value[len(value)-1] || (value[0] != '"' && value[0] != '\'') {
+		return value
+	}
+	inner := value[1 : len(value)-1]
+	if value[0] == '\'' {
+		return inner
+	}
+	var b strings.Builder
+	for i := 0; i < len(inner); i++ {
+		if inner[i] == '\\' && i+1 < len(inner) && (inner[i+1] == '"' || inner[i+1] == '\\') {
+			i++
+		}
+		b.WriteByte(inner[i])
+	}
+	return b.String()
+}
+
+// Reports unusual indentation of a raw line to the WarnFunc, if any.
+func (d *Decoder) warnIndent(raw, msg string) {
+	if d.opts.WarnFunc == nil {
+		return
+	}
+	indent := raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
+	if strings.Contains(indent, " ") && strings.Contains(indent, "\t") {
+		d.opts.WarnFunc(d.lineNum, "indentation mixes tabs and spaces")
+	} else if indent != "" {
+		d.opts.WarnFunc(d.lineNum, msg)
+	}
+}
+
+// Reads the remaining entries into file.
+func (d *Decoder) decodeInto(file File) (err error) {
+	defer func() {
+		// Entries from an included file can fail checks here, such as MaxSections
+		if err != nil {
+			d.closeIncluded()
+		}
+	}()
+	var block Section
+	blockSection := ""
+	current, start := d.section, d.offset
+	closeRange := func(end int64) {
+		if _, ok := file[current]; ok && d.opts.TrackOffsets {
+			if d.ranges == nil {
+				d.ranges = make(map[string][2]int64)
+			}
+			d.ranges[current] = [2]int64{start, end}
+		}
+	}
+	comment := func(section, key string) {
+		if len(d.commentLines) > 0 && d.opts.CollectComments {
+			if d.comments == nil {
+				d.comments = make(map[[2]string]string)
+			}
+			d.comments[[2]string{section, key}] = strings.Join(d.commentLines, "\n")
+		}
+	}
+	for {
+		section, key, value, err := d.Next()
+		if err == io.EOF {
+			comment(current, "")
+			closeRange(d.offset)
+			if d.opts.Validate != nil {
+				return d.opts.Validate(file)
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if key != "" {
+			if err := d.checkLimits(file, section, key); err != nil {
+				return err
+			}
+			if !d.included {
+				comment(section, key)
+			}
+			file.Section(section)[key] = value
+			if block != nil && section == blockSection {
+				block[key] = value
+			}
+			continue
+		}
+		if err := d.checkLimits(file, section, ""); err != nil {
+			return err
+		}
+		// Create the section if it does not exist
+		file.Section(section)
+		// Offsets, comments and blocks are only tracked for the top-level input, so headers from included
+		// files, and the one returning to the including section, leave the current range and block running
+		if d.included {
+			continue
+		}
+		comment(current, "")
+		closeRange(d.lineStart)
+		current, start = section, d.lineStart
+		if d.opts.RepeatedSections {
+			block, blockSection = make(Section), section
+			if d.blocks == nil {
+				d.blocks = make(map[string][]Section)
+			}
+			d.blocks[section] = append(d.blocks[section], block)
+		}
+	}
+}
+
+// Returns an error if storing key in section, or just creating section when key is empty, would exceed
+// MaxSections or MaxKeysPerSection.
+func (d *Decoder) checkLimits(file File, section, key string) error {
+	s, exists := file[section]
+	if !exists && d.opts.MaxSections > 0 && len(file) >= d.opts.MaxSections {
+		return fmt.Errorf("line %d: more than %d sections (MaxSections)", d.lineNum, d.opts.MaxSections)
+	}
+	if _, ok := s[key]; key != "" && !ok && d.opts.MaxKeysPerSection > 0 && len(s) >= d.opts.MaxKeysPerSection {
+		return fmt.Errorf("line %d: more than %d keys in section %q (MaxKeysPerSection)", d.lineNum,
+			d.opts.MaxKeysPerSection, section)
+	}
+	return nil
+}
@@ -0,0 +1,477 @@
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoderNext(t *testing.T) {
+	d := NewDecoder(strings.NewReader("top = 1\n[Foo]\n; comment\nBar = baz\n[empty]"))
+	type entry struct{ section, key, value string }
+	var entries []entry
+	for {
+		section, key, value, err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry{section, key, value})
+	}
+	expect := []entry{{"", "top", "1"}, {"foo", "", ""}, {"foo", "bar", "baz"}, {"empty", "", ""}}
+	if !reflect.DeepEqual(entries, expect) {
+		t.Errorf("expected %v, got %v", expect, entries)
+	}
+}
+
+func TestDecoderDecode(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader("[a]\nx = 1\n"))
+	d := NewDecoder(in)
+	d.SetOptions(ParseOptions{CaseSensitive: true})
+	file, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := (File{"a": {"x": "1"}}); !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+	if _, err := NewDecoder(strings.NewReader("wut?")).Decode(); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestWarnFunc(t *testing.T) {
+	src := "[a]\nx = 1\n  y = 2\n\t z = 3\n  ; indented comment\n [b]\n"
+	var warnings []string
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{
+		WarnFunc: func(line int, msg string) {
+			warnings = append(warnings, fmt.Sprintf("%d: %s", line, msg))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"3: indented property may be meant as a continuation of the previous value",
+		"4: indentation mixes tabs and spaces",
+		"6: indented section header",
+	}
+	if !reflect.DeepEqual(warnings, expect) {
+		t.Errorf("expected %q, got %q", expect, warnings)
+	}
+	if value, _ := file.Get("a", "z"); value != "3" {
+		t.Error("warnings changed parsing")
+	}
+}
+
+// Decodes src with opts, returning the Decoder for inspecting what it recorded.
+func decodeWith(t *testing.T, src string, opts ParseOptions) (*Decoder, File) {
+	t.Helper()
+	d := NewDecoder(strings.NewReader(src))
+	d.SetOptions(opts)
+	file, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, file
+}
+
+func TestSectionRange(t *testing.T) {
+	src := "top = 1\n[a]\nx = 1\n\n; about b\n[b]\ny = 2\n"
+	d, _ := decodeWith(t, src, ParseOptions{TrackOffsets: true})
+	check := func(name, expect string) {
+		start, end, ok := d.SectionRange(name)
+		if !ok {
+			t.Fatalf("SectionRange(%q): no range recorded", name)
+		}
+		if src[start:end] != expect {
+			t.Errorf("SectionRange(%q): expected %q, got %q", name, expect, src[start:end])
+		}
+	}
+	check("", "top = 1\n")
+	check("a", "[a]\nx = 1\n\n; about b\n")
+	check("b", "[b]\ny = 2\n")
+	if _, _, ok := d.SectionRange("missing"); ok {
+		t.Error("expected no range for a missing section")
+	}
+
+	plain, _ := decodeWith(t, src, ParseOptions{})
+	if _, _, ok := plain.SectionRange("a"); ok {
+		t.Error("expected offsets not to be tracked by default")
+	}
+}
+
+func TestInlineComments(t *testing.T) {
+	src := "[server] ; production\nhost = example.com # primary\nurl = http://x/#anchor\nratio = 1;2\n"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{InlineComments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{"server": {"host": "example.com", "url": "http://x/#anchor", "ratio": "1;2"}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+
+	_, err = Load(strings.NewReader(src))
+	if syntaxErr, ok := err.(ErrSyntax); !ok || syntaxErr.Line != 1 {
+		t.Errorf("expected a trailing header comment to be an error by default, got %v", err)
+	}
+}
+
+func TestRejectEmptySection(t *testing.T) {
+	src := "top = 1\n[a]\nx = 1\n[ ]\ny = 2\n"
+	_, err := LoadWith(strings.NewReader(src), ParseOptions{RejectEmptySection: true})
+	if syntaxErr, ok := err.(ErrSyntax); !ok || syntaxErr.Line != 4 || syntaxErr.Source != "[ ]" {
+		t.Errorf("expected an ErrSyntax on line 4, got %v", err)
+	}
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := file.Get("", "y"); value != "2" {
+		t.Error("expected an empty header to select the default section by default")
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	src := "a = \"  padded  \"\nb = \"say \\\"hi\\\"\"\nc = \"unterminated\nd = plain \"mid\" text\n"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{Quotes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{"": {"a": "  padded  ", "b": `say "hi"`, "c": `"unterminated`, "d": `plain "mid" text`}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+}
+
+func TestDefaultSectionName(t *testing.T) {
+	src := "name = app\n[server]\nhost = a\n[]\nlate = 1\n"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{DefaultSectionName: "global"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{"global": {"name": "app", "late": "1"}, "server": {"host": "a"}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+}
+
+func TestSingleQuotes(t *testing.T) {
+	src := "a = '  padded  '\nb = 'keep \\\" as is'\nc = 'mismatched\"\nd = \"mismatched'\ne = 'x ; y' ; comment\nf = 'it''s'\n"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{Quotes: true, InlineComments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{"": {
+		"a": "  padded  ",
+		"b": `keep \" as is`,
+		"c": `'mismatched"`,
+		"d": `"mismatched'`,
+		"e": "x ; y",
+		"f": "it''s",
+	}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+	plain, err := Load(strings.NewReader("a = 'quoted'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := plain.Get("", "a"); value != "'quoted'" {
+		t.Errorf("expected quotes to be kept by default, got %q", value)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	src := "top = 1\n[a]\nx = 1\ny = 2\nx = 3\n[b]\n[a]\nz = 3\n"
+	if _, err := LoadWith(strings.NewReader(src), ParseOptions{MaxSections: 3, MaxKeysPerSection: 2}); err == nil {
+		t.Error("expected the key limit to be hit")
+	} else if expect := `line 8: more than 2 keys in section "a" (MaxKeysPerSection)`; err.Error() != expect {
+		t.Errorf("expected %q, got %q", expect, err.Error())
+	}
+	if _, err := LoadWith(strings.NewReader(src), ParseOptions{MaxSections: 2}); err == nil {
+		t.Error("expected the section limit to be hit")
+	} else if expect := "line 6: more than 2 sections (MaxSections)"; err.Error() != expect {
+		t.Errorf("expected %q, got %q", expect, err.Error())
+	}
+	if _, err := LoadWith(strings.NewReader(src), ParseOptions{MaxSections: 3, MaxKeysPerSection: 3}); err != nil {
+		t.Errorf("expected no error within the limits, got %v", err)
+	}
+}
+
+func TestNameFuncs(t *testing.T) {
+	errBadName := errors.New("bad name")
+	opts := ParseOptions{
+		SectionNameFunc: func(name string) error {
+			if strings.Contains(name, " ") {
+				return errBadName
+			}
+			return nil
+		},
+		KeyNameFunc: func(key string) error {
+			if strings.HasPrefix(key, "_") {
+				return errBadName
+			}
+			return nil
+		},
+	}
+	if _, err := LoadWith(strings.NewReader("[ok]\na = 1\n[not ok]\n"), opts); !errors.Is(err, errBadName) || !strings.HasPrefix(err.Error(), "line 3: ") {
+		t.Errorf("expected a wrapped section name error on line 3, got %v", err)
+	}
+	if _, err := LoadWith(strings.NewReader("[ok]\n_a = 1\n"), opts); !errors.Is(err, errBadName) || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Errorf("expected a wrapped key name error on line 2, got %v", err)
+	}
+	if _, err := LoadWith(strings.NewReader("[ok]\na = 1\n"), opts); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestRawSections(t *testing.T) {
+	pem := "-----BEGIN CERTIFICATE-----\n  MIIB; not = a comment\n# kept\n\n-----END CERTIFICATE-----"
+	src := "[server]\nhost = a\n[cert|raw]\n" + pem + "\n\n[after]\nx = 1\n[tail|RAW]\nlast line"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{RawSections: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{
+		"server": {"host": "a"},
+		"cert":   {RawKey: pem},
+		"after":  {"x": "1"},
+		"tail":   {RawKey: "last line"},
+	}
+	if !reflect.DeepEqual(file, expect) {
+		t.Fatalf("expected %v, got %v", expect, file)
+	}
+
+	var buf bytes.Buffer
+	if err := file.WriteWith(&buf, WriteOptions{RawSections: true}); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadWith(&buf, ParseOptions{RawSections: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reloaded, expect) {
+		t.Errorf("round trip: expected %v, got %v", expect, reloaded)
+	}
+}
+
+func TestEscapedSectionNames(t *testing.T) {
+	src := "[a\\]b]\nx = 1\n[plain]b]\ny = 2\n[back\\\\slash\\]\nz = 3\n"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{EscapedSectionNames: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{"a]b": {"x": "1"}, "plain]b": {"y": "2"}, `back\slash\`: {"z": "3"}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Fatalf("expected %v, got %v", expect, file)
+	}
+	unescaped, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := unescaped[`a\]b`]; !ok {
+		t.Errorf("expected names to be taken literally by default, got %v", unescaped)
+	}
+
+	var buf bytes.Buffer
+	if err := file.WriteWith(&buf, WriteOptions{EscapeSectionNames: true}); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadWith(&buf, ParseOptions{EscapedSectionNames: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reloaded, expect) {
+		t.Errorf("round trip: expected %v, got %v", expect, reloaded)
+	}
+}
+
+func TestStripQuotes(t *testing.T) {
+	src := "a = \"quoted\"\nb = 'single'\nc = \"back\\\"slash\"\nd = \"mismatched'\ne = \"\nf = \" padded \"\n"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{StripQuotes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{"": {"a": "quoted", "b": "single", "c": `back\"slash`, "d": `"mismatched'`, "e": `"`, "f": " padded "}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+}
+
+func TestTabDelimiter(t *testing.T) {
+	src := "name\tgo-ini\n[paths]\nroot\t/srv\tdata\nmixed = a\tb\ntabbed\t\tpadded\n"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{TabDelimiter: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{
+		"":      {"name": "go-ini"},
+		"paths": {"root": "/srv\tdata", "mixed": "a\tb", "tabbed": "padded"},
+	}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+
+	if _, err := Load(strings.NewReader("name\tgo-ini\n")); err == nil {
+		t.Error("expected an error without TabDelimiter")
+	}
+}
+
+func TestCollectComments(t *testing.T) {
+	src := "; Listen address\n; of the server\naddr = :80\n\n[db]\n# Connection string\ndsn = postgres://\nport = 5432\n; end of db\n[cache]\nttl = 1m\n; trailing\n"
+	d, _ := decodeWith(t, src, ParseOptions{CollectComments: true})
+	for _, c := range []struct{ section, key, comment string }{
+		{"", "addr", "Listen address\nof the server"},
+		{"db", "dsn", "Connection string"},
+		{"db", "port", ""},
+		{"db", "", "end of db"},
+		{"cache", "ttl", ""},
+		{"cache", "", "trailing"},
+	} {
+		if comment := d.Comment(c.section, c.key); comment != c.comment {
+			t.Errorf("Comment(%q, %q): expected %q, got %q", c.section, c.key, c.comment, comment)
+		}
+	}
+
+	d, _ = decodeWith(t, src, ParseOptions{})
+	if comment := d.Comment("", "addr"); comment != "" {
+		t.Errorf("expected no comments without CollectComments, got %q", comment)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	errNoCert := errors.New("[tls] requires cert")
+	opts := ParseOptions{Validate: func(f File) error {
+		if tls, ok := f["tls"]; ok && tls["cert"] == "" {
+			return errNoCert
+		}
+		return nil
+	}}
+	file, err := LoadWith(strings.NewReader("[tls]\nkey = k.pem\n"), opts)
+	if err != errNoCert {
+		t.Errorf("expected %v, got %v", errNoCert, err)
+	}
+	if file["tls"]["key"] != "k.pem" {
+		t.Errorf("expected the File alongside the error, got %v", file)
+	}
+	if _, err := LoadWith(strings.NewReader("[tls]\ncert = c.pem\n"), opts); err != nil {
+		t.Error(err)
+	}
+
+	called := false
+	opts.Validate = func(File) error { called = true; return nil }
+	if _, err := LoadWith(strings.NewReader("bad line\n"), opts); err == nil || called {
+		t.Errorf("expected a syntax error without validation, got %v, called=%v", err, called)
+	}
+}
+
+// Decodes Latin-1 into UTF-8, standing in for a golang.org/x/text transformer.
+type latin1Reader struct{ r io.Reader }
+
+func (l latin1Reader) Read(p []byte) (int, error) {
+	buf := make([]byte, len(p)/2)
+	n, err := l.r.Read(buf)
+	var out []byte
+	for _, c := range buf[:n] {
+		out = append(out, string(rune(c))...)
+	}
+	return copy(p, out), err
+}
+
+func TestTransform(t *testing.T) {
+	src := "[caf\xe9]\nna\xefve = cr\xe8me\n"
+	opts := ParseOptions{Transform: func(r io.Reader) io.Reader { return latin1Reader{r} }}
+	file, err := LoadWith(strings.NewReader(src), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{"café": {"naïve": "crème"}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+}
+
+func TestIndentContinuation(t *testing.T) {
+	src := "motd = Welcome\n  to the server\n\tenjoy\nnext = 1\n  [db]\nhosts = a\n  b\n\n; done\n"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{IndentContinuation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{
+		"":   {"motd": "Welcome\nto the server\nenjoy", "next": "1"},
+		"db": {"hosts": "a\nb"},
+	}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+
+	for _, src := range []string{"  orphan\n", "[s]\n  orphan\n", "a = 1\n\n  after blank\n"} {
+		if _, err := LoadWith(strings.NewReader(src), ParseOptions{IndentContinuation: true}); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+	if _, err := Load(strings.NewReader("a = 1\n  b\n")); err == nil {
+		t.Error("expected an error without IndentContinuation")
+	}
+}
+
+func TestFileDirective(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(cert, []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	src := "[tls]\ncert = @file:" + cert + "\nname = literal\n"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{FileDirective: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"; file["tls"]["cert"] != expect {
+		t.Errorf("expected %q, got %q", expect, file["tls"]["cert"])
+	}
+
+	file, err = Load(strings.NewReader(src))
+	if err != nil || file["tls"]["cert"] != "@file:"+cert {
+		t.Errorf("expected the directive to be kept literally, got %q, %v", file["tls"]["cert"], err)
+	}
+
+	missing := filepath.Join(dir, "missing.pem")
+	_, err = LoadWith(strings.NewReader("key = @file:"+missing+"\n"), ParseOptions{FileDirective: true})
+	if err == nil || !strings.Contains(err.Error(), `"key"`) || !strings.Contains(err.Error(), missing) {
+		t.Errorf("expected an error naming the key and path, got %v", err)
+	}
+}
+
+func TestSkipLines(t *testing.T) {
+	file, err := LoadWith(strings.NewReader("#!/usr/bin/env tool\nnot ini\na = 1\n"), ParseOptions{SkipLines: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := (File{"": {"a": "1"}}); !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+
+	src := "title: notes\n[not a section]\n  ---  \n[s]\nb = 2\noops\n"
+	_, err = LoadWith(strings.NewReader(src), ParseOptions{StartMarker: "---"})
+	if e, ok := err.(ErrSyntax); !ok || e.Line != 6 {
+		t.Errorf("expected a syntax error on line 6, got %v", err)
+	}
+	file, err = LoadWith(strings.NewReader(src), ParseOptions{StartMarker: "---", OnError: func(int, string) error { return nil }})
+	if expect := (File{"s": {"b": "2"}}); err != nil || !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v, %v", expect, file, err)
+	}
+
+	if _, err := LoadWith(strings.NewReader("a = 1\n"), ParseOptions{StartMarker: "---"}); err == nil {
+		t.Error("expected an error for a missing start marker")
+	}
+}
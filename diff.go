@@ -0,0 +1,61 @@
+package ini
+
+import "os"
+
+// A Change is a key whose value differs between two Files. Old is empty for an added key and New for a
+// removed one.
+type Change struct {
+	Section, Key string
+	Old, New     string
+}
+
+// Changes describes how a File differs from a base File, as reported by File.Diff. Each list is sorted
+// by section and then key.
+type Changes struct {
+	Added    []Change // Keys missing from the base
+	Removed  []Change // Keys missing from the File
+	Modified []Change // Keys present in both with different values
+}
+
+// Reports whether there are no changes.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// Returns the changes that turn base into the File. Only keys are compared, so a section without keys
+// that exists in just one of them is not reported.
+func (f File) Diff(base File) Changes {
+	var changes Changes
+	for _, name := range sortedSections(f) {
+		for _, key := range sortedKeys(f[name]) {
+			value := f[name][key]
+			if old, ok := base[name][key]; !ok {
+				changes.Added = append(changes.Added, Change{name, key, "", value})
+			} else if old != value {
+				changes.Modified = append(changes.Modified, Change{name, key, old, value})
+			}
+		}
+	}
+	for _, name := range sortedSections(base) {
+		for _, key := range sortedKeys(base[name]) {
+			if _, ok := f[name][key]; !ok {
+				changes.Removed = append(changes.Removed, Change{name, key, base[name][key], ""})
+			}
+		}
+	}
+	return changes
+}
+
+// Returns the changes that turn the named file, as loaded by LoadFile, into the File, for detecting
+// whether the File needs to be written back. A file that does not exist counts as empty, so every key
+// of the File is added. Other errors loading the file are returned.
+func (f File) DiffFile(filename string) (Changes, error) {
+	base, err := LoadFile(filename)
+	if os.IsNotExist(err) {
+		base, err = nil, nil
+	}
+	if err != nil {
+		return Changes{}, err
+	}
+	return f.Diff(base), nil
+}
@@ -0,0 +1,50 @@
+package ini
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	base := File{"": {"name": "app"}, "db": {"host": "localhost", "port": "5432"}, "old": {"x": "1"}}
+	file := File{"": {"name": "app"}, "db": {"host": "db.internal", "pool": "10"}, "new": {}}
+	expect := Changes{
+		Added:    []Change{{"db", "pool", "", "10"}},
+		Removed:  []Change{{"db", "port", "5432", ""}, {"old", "x", "1", ""}},
+		Modified: []Change{{"db", "host", "localhost", "db.internal"}},
+	}
+	if changes := file.Diff(base); !reflect.DeepEqual(changes, expect) {
+		t.Errorf("expected %+v, got %+v", expect, changes)
+	}
+	if changes := base.Diff(base); !changes.Empty() {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestDiffFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.ini")
+	file := File{"db": {"host": "localhost"}}
+
+	changes, err := file.DiffFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []Change{{"db", "host", "", "localhost"}}; !reflect.DeepEqual(changes.Added, expect) || len(changes.Removed) != 0 {
+		t.Errorf("expected everything to be added, got %+v", changes)
+	}
+
+	if err := file.WriteFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := file.DiffFile(filename); err != nil || !changes.Empty() {
+		t.Errorf("expected no changes, got %+v, %v", changes, err)
+	}
+
+	os.WriteFile(filename, []byte("not ini\n"), 0644)
+	if _, err := file.DiffFile(filename); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
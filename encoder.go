@@ -0,0 +1,34 @@
+package ini
+
+import "io"
+
+// An Encoder writes Files to an output stream using a reusable set of WriteOptions.
+type Encoder struct {
+	w    io.Writer
+	opts WriteOptions
+}
+
+// Returns a new Encoder writing to w with the default options.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Sets all options used by Encode.
+func (e *Encoder) SetOptions(opts WriteOptions) {
+	e.opts = opts
+}
+
+// Sets the separator written between keys and values, such as "=" or " = ".
+func (e *Encoder) SetSeparator(sep string) {
+	e.opts.Separator = sep
+}
+
+// Sets whether a blank line is written between sections, which is the default.
+func (e *Encoder) SetBlankLines(blank bool) {
+	e.opts.OmitBlankLines = !blank
+}
+
+// Writes f to the stream in INI format, as File.WriteWith does.
+func (e *Encoder) Encode(f File) error {
+	return f.WriteWith(e.w, e.opts)
+}
@@ -0,0 +1,35 @@
+package ini
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	file := File{"a": {"x": "1"}, "b": {"y": "2"}}
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetSeparator("=")
+	e.SetBlankLines(false)
+	if err := e.Encode(file); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "[a]\nx=1\n[b]\ny=2\n"; buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+
+	buf.Reset()
+	e.SetBlankLines(true)
+	e.SetSeparator(" =  ")
+	if err := e.Encode(file); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "[a]\nx =  1\n\n[b]\ny =  2\n"; buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+
+	e.SetSeparator(":")
+	if err := e.Encode(file); err == nil {
+		t.Error("expected an error for a separator without =")
+	}
+}
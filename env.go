@@ -0,0 +1,74 @@
+package ini
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Returns the File as environment entries of the form PREFIX_SECTION_KEY=value, suitable for
+// exec.Cmd.Env. Names are uppercased with every character other than ASCII letters and digits replaced
+// by "_", and keys of the default section become PREFIX_KEY. An empty prefix is left out. When several
+// keys map to the same name, the one visited last in sorted section and key order wins. The entries are
+// sorted by name.
+func (f File) ToEnv(prefix string) []string {
+	values := make(map[string]string)
+	for _, name := range sortedSections(f) {
+		section := f[name]
+		for _, key := range sortedKeys(section) {
+			var parts []string
+			for _, part := range []string{prefix, name, key} {
+				if part != "" {
+					parts = append(parts, envName(part))
+				}
+			}
+			values[strings.Join(parts, "_")] = section[key]
+		}
+	}
+	env := make([]string, 0, len(values))
+	for name, value := range values {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Uppercases s and replaces everything but ASCII letters and digits with "_".
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// Builds a File from the environment variables named PREFIX_..., the inverse of ToEnv. After removing
+// the prefix and its "_", the rest of the name is split at its first "_" into a section and a key, both
+// lowercased, so APP_DB_MAX_CONNS becomes key "max_conns" in section [db]. A name without another "_",
+// like APP_DEBUG, becomes a key of the default section. Since "_" always ends the section name, section
+// names containing "_", ".", "-" or other characters mapped to "_" by ToEnv cannot be recovered, and
+// keys only round-trip if they contain no characters other than letters, digits and "_". An empty
+// prefix reads every environment variable.
+func FromEnv(prefix string) File {
+	file := make(File)
+	if prefix != "" {
+		prefix += "_"
+	}
+	for _, entry := range os.Environ() {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		name = strings.ToLower(name[len(prefix):])
+		section, key, ok := strings.Cut(name, "_")
+		if !ok || section == "" || key == "" {
+			section, key = "", name
+		}
+		file.Section(section)[key] = value
+	}
+	return file
+}
@@ -0,0 +1,45 @@
+package ini
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToEnv(t *testing.T) {
+	file := File{
+		"":          {"debug": "true"},
+		"db":        {"host": "localhost", "max-conns": "10"},
+		"db.max":    {"conns": "20"},
+		"cache.lru": {"size": "1M"},
+	}
+	expect := []string{
+		"APP_CACHE_LRU_SIZE=1M",
+		"APP_DB_HOST=localhost",
+		"APP_DB_MAX_CONNS=20",
+		"APP_DEBUG=true",
+	}
+	if env := file.ToEnv("app"); !reflect.DeepEqual(env, expect) {
+		t.Errorf("expected %q, got %q", expect, env)
+	}
+	if env := (File{"": {"x": "1"}}).ToEnv(""); !reflect.DeepEqual(env, []string{"X=1"}) {
+		t.Errorf("expected no prefix, got %q", env)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("INITEST_DEBUG", "true")
+	t.Setenv("INITEST_DB_HOST", "localhost")
+	t.Setenv("INITEST_DB_MAX_CONNS", "10")
+	t.Setenv("INITESTX_OTHER", "ignored")
+	expect := File{
+		"":   {"debug": "true"},
+		"db": {"host": "localhost", "max_conns": "10"},
+	}
+	file := FromEnv("INITEST")
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+	if env := file.ToEnv("INITEST"); !reflect.DeepEqual(env, []string{"INITEST_DB_HOST=localhost", "INITEST_DB_MAX_CONNS=10", "INITEST_DEBUG=true"}) {
+		t.Errorf("expected ToEnv to invert FromEnv, got %q", env)
+	}
+}
@@ -0,0 +1,116 @@
+package ini
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The limit on nested includes used when ParseOptions.MaxIncludeDepth is zero.
+const DefaultMaxIncludeDepth = 10
+
+// An error about the include chain itself. It already names every file involved, so the including files
+// do not annotate it again.
+type includeError struct{ msg string }
+
+func (e includeError) Error() string { return e.msg }
+
+// Starts reading the named file in place of the current line, checking for cycles and the depth limit.
+func (d *Decoder) include(name string) error {
+	if !filepath.IsAbs(name) && len(d.chain) > 0 {
+		name = filepath.Join(filepath.Dir(d.chain[len(d.chain)-1]), name)
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", d.lineNum, err)
+	}
+	chain := append(append([]string(nil), d.chain...), abs)
+	for _, path := range d.chain {
+		if path == abs {
+			return includeError{fmt.Sprintf("line %d: include cycle: %s", d.lineNum, strings.Join(chain, " -> "))}
+		}
+	}
+	max := d.opts.MaxIncludeDepth
+	if max <= 0 {
+		max = DefaultMaxIncludeDepth
+	}
+	if d.depth >= max {
+		return includeError{fmt.Sprintf("line %d: include depth exceeds %d: %s", d.lineNum, max,
+			strings.Join(chain, " -> "))}
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", d.lineNum, err)
+	}
+	var r io.Reader = f
+	if d.opts.Transform != nil {
+		r = d.opts.Transform(r)
+	}
+	d.sub, d.subFile = NewDecoder(r), f
+	opts := d.opts
+	opts.SkipLines, opts.StartMarker = 0, ""
+	d.sub.SetOptions(opts)
+	d.sub.chain, d.sub.depth = chain, d.depth+1
+	return nil
+}
+
+// Returns the next entry of the included file being read. At its end the file is closed and, if it
+// switched sections, a header for the including section is returned so later entries land there;
+// otherwise io.EOF tells Next to carry on with its own input. Errors are annotated with the file name,
+// except that syntax errors collected with CollectErrors are added to the Decoder's own.
+func (d *Decoder) nextIncluded() (section, key, value string, err error) {
+	if section, key, value, err = d.sub.Next(); err == nil {
+		d.included = true
+		return
+	}
+	sub := d.sub
+	d.closeIncluded()
+	if list, ok := err.(ErrSyntaxList); ok && d.opts.CollectErrors {
+		for _, e := range list {
+			if e.File == "" {
+				e.File = sub.chain[len(sub.chain)-1]
+			}
+			d.errs = append(d.errs, e)
+		}
+		err = io.EOF
+	}
+	if err != io.EOF {
+		if _, ok := err.(includeError); !ok {
+			err = fmt.Errorf("%s: %w", sub.chain[len(sub.chain)-1], err)
+		}
+		return "", "", "", err
+	}
+	if sub.section != d.section {
+		d.included = true
+		return d.section, "", "", nil
+	}
+	return "", "", "", io.EOF
+}
+
+// Closes any included file the Decoder is part-way through and, for a Decoder returned by NewFileDecoder,
+// the file it reads. Decode and the loaders close included files themselves when they fail, and included
+// files are closed as soon as they have been read, so for a Decoder returned by NewDecoder Close is only
+// needed after abandoning Next. It never closes the reader passed to NewDecoder.
+func (d *Decoder) Close() error {
+	err := d.closeIncluded()
+	if d.file != nil {
+		if ferr := d.file.Close(); err == nil {
+			err = ferr
+		}
+		d.file = nil
+	}
+	return err
+}
+
+// Closes the included file being read, if any, along with those it includes.
+func (d *Decoder) closeIncluded() error {
+	if d.sub == nil {
+		return nil
+	}
+	d.sub.closeIncluded()
+	err := d.subFile.Close()
+	d.sub, d.subFile = nil, nil
+	return err
+}
@@ -0,0 +1,185 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "conf.d"), 0755)
+	writeFiles(t, dir, map[string]string{
+		"main.ini":         "name = app\n[server]\nport = 80\n@include conf.d/db.ini\nhost = example.com\n",
+		"conf.d/db.ini":    "root = 1\n[db]\nhost = localhost\n@include extra.ini\n",
+		"conf.d/extra.ini": "port = 5432\n",
+	})
+	opts := ParseOptions{Include: true}
+	file, err := LoadFileWith(filepath.Join(dir, "main.ini"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{
+		"":       {"name": "app", "root": "1", "port": "5432"},
+		"server": {"port": "80", "host": "example.com"},
+		"db":     {"host": "localhost"},
+	}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+
+	if _, err := LoadFileWith(filepath.Join(dir, "main.ini"), ParseOptions{}); err == nil {
+		t.Error("expected @include to be a syntax error when disabled")
+	}
+}
+
+func TestIncludeLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.ini":       "@include b.ini\n",
+		"b.ini":       "@include a.ini\n",
+		"missing.ini": "@include nowhere.ini\n",
+		"bad.ini":     "@include syntax.ini\n",
+		"syntax.ini":  "oops\n",
+	})
+	for i := 0; i < 5; i++ {
+		writeFiles(t, dir, map[string]string{fmt.Sprintf("chain%d.ini", i): fmt.Sprintf("@include chain%d.ini\n", i+1)})
+	}
+	writeFiles(t, dir, map[string]string{"chain5.ini": "end = 1\n"})
+
+	_, err := LoadFileWith(filepath.Join(dir, "a.ini"), ParseOptions{Include: true})
+	if err == nil || !strings.Contains(err.Error(), "include cycle") || strings.Count(err.Error(), "a.ini") != 2 {
+		t.Errorf("expected a cycle error listing the chain, got %v", err)
+	}
+
+	chain := filepath.Join(dir, "chain0.ini")
+	if _, err := LoadFileWith(chain, ParseOptions{Include: true}); err != nil {
+		t.Errorf("expected the default depth to allow 5 levels, got %v", err)
+	}
+	_, err = LoadFileWith(chain, ParseOptions{Include: true, MaxIncludeDepth: 3})
+	if err == nil || !strings.Contains(err.Error(), "include depth exceeds 3") || !strings.Contains(err.Error(), "chain4.ini") {
+		t.Errorf("expected a depth error listing the chain, got %v", err)
+	}
+	if strings.Count(err.Error(), "chain3.ini") != 1 {
+		t.Errorf("expected the chain to be listed once, got %v", err)
+	}
+
+	if _, err := LoadFileWith(filepath.Join(dir, "missing.ini"), ParseOptions{Include: true}); !os.IsNotExist(errors.Unwrap(err)) {
+		t.Errorf("expected a missing file error, got %v", err)
+	}
+	_, err = LoadFileWith(filepath.Join(dir, "bad.ini"), ParseOptions{Include: true})
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(dir, "syntax.ini")) {
+		t.Errorf("expected a syntax error naming the included file, got %v", err)
+	}
+}
+
+func TestIncludeOffsets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"db.ini": "; about db\n[db]\nhost = localhost\n",
+	})
+	src := "[server]\nport = 80\n@include " + filepath.Join(dir, "db.ini") + "\nhost = x\n[tail]\n"
+	d, file := decodeWith(t, src, ParseOptions{Include: true, TrackOffsets: true, CollectComments: true})
+	if file["server"]["host"] != "x" || file["db"]["host"] != "localhost" {
+		t.Fatalf("unexpected File %v", file)
+	}
+	start, end, ok := d.SectionRange("server")
+	if expect := src[:strings.Index(src, "[tail]")]; !ok || src[start:end] != expect {
+		t.Errorf("expected the server range to be %q, got %q, %v", expect, src[start:end], ok)
+	}
+	if _, _, ok := d.SectionRange("db"); ok {
+		t.Error("expected no range for a section from an included file")
+	}
+	if start, end, ok := d.SectionRange("tail"); !ok || src[start:end] != "[tail]\n" {
+		t.Errorf("expected the tail range to be %q, got %q, %v", "[tail]\n", src[start:end], ok)
+	}
+	if comment := d.Comment("db", "host"); comment != "" {
+		t.Errorf("expected no comments from included files, got %q", comment)
+	}
+}
+
+func TestIncludeRepeatedSections(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"inc.ini": "x = 1\n[other]\ny = 2\n"})
+	src := "[a]\nk = 1\n@include " + filepath.Join(dir, "inc.ini") + "\nz = 3\n[a]\nq = 4\n"
+	d, file := decodeWith(t, src, ParseOptions{Include: true, RepeatedSections: true})
+	if file[""]["x"] != "1" || file["other"]["y"] != "2" {
+		t.Fatalf("unexpected File %v", file)
+	}
+	expect := []Section{{"k": "1", "z": "3"}, {"q": "4"}}
+	if blocks := d.SectionList("a"); !reflect.DeepEqual(blocks, expect) {
+		t.Errorf("expected blocks %v, got %v", expect, blocks)
+	}
+	if blocks := d.SectionList("other"); blocks != nil {
+		t.Errorf("expected no blocks for a section from an included file, got %v", blocks)
+	}
+}
+
+func TestIncludeCollectErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.ini":   "bad\n[a]\n@include inc.ini\nz = 3\n",
+		"inc.ini":    "oops\nx = 1\n@include nested.ini\n",
+		"nested.ini": "\n[b]\nwut?\n",
+	})
+	file, err := LoadFileWith(filepath.Join(dir, "main.ini"), ParseOptions{Include: true, CollectErrors: true})
+	list, ok := err.(ErrSyntaxList)
+	if !ok {
+		t.Fatalf("expected an ErrSyntaxList, got %v", err)
+	}
+	expect := []ErrSyntax{
+		{Line: 1, Source: "bad"},
+		{Line: 1, Source: "oops", File: filepath.Join(dir, "inc.ini")},
+		{Line: 3, Source: "wut?", File: filepath.Join(dir, "nested.ini")},
+	}
+	if !reflect.DeepEqual(list.Errors(), expect) {
+		t.Errorf("expected %v, got %v", expect, list.Errors())
+	}
+	if !strings.Contains(list.Error(), "in "+filepath.Join(dir, "inc.ini")+" on line 1: oops") {
+		t.Errorf("expected the message to name the included file, got %q", list.Error())
+	}
+	if file[""]["x"] != "1" || file["a"]["z"] != "3" {
+		t.Errorf("expected parsing to continue past errors in included files, got %v", file)
+	}
+}
+
+func TestNewFileDecoder(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.ini": "[row]\nid = 1\n@include rows.ini\n[row]\nid = 3\n",
+		"rows.ini": "[extra]\nx = 1\n",
+	})
+	d, err := NewFileDecoder(filepath.Join(dir, "main.ini"), ParseOptions{Include: true, RepeatedSections: true, TrackOffsets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	file, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file["extra"]["x"] != "1" || file["row"]["id"] != "3" {
+		t.Fatalf("unexpected File %v", file)
+	}
+	if blocks := d.SectionList("row"); !reflect.DeepEqual(blocks, []Section{{"id": "1"}, {"id": "3"}}) {
+		t.Errorf("unexpected blocks %v", blocks)
+	}
+	if start, _, ok := d.SectionRange("row"); !ok || start != int64(len("[row]\nid = 1\n@include rows.ini\n")) {
+		t.Errorf("unexpected range start %d, %v", start, ok)
+	}
+	if _, err := NewFileDecoder(filepath.Join(dir, "missing.ini"), ParseOptions{}); !os.IsNotExist(err) {
+		t.Errorf("expected a missing file error, got %v", err)
+	}
+}
@@ -3,17 +3,19 @@ package ini
 
 import (
 	"bufio"
+	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 var (
-	sectionRegex  = regexp.MustCompile(`^\[(.*)\]$`)
-	assignRegex   = regexp.MustCompile(`^([^=]+)=(.*)$`)
 	descRegex     = regexp.MustCompile(`(?m)(?i)^\[(description)\]$`)
 	TimerSections = make(TimeMap)
 )
@@ -22,18 +24,210 @@ var (
 type ErrSyntax struct {
 	Line   int
 	Source string // The contents of the erroneous line, without leading or trailing whitespace
+	File   string // The included file the line is in, or empty for the input being parsed
 }
 
 func (e ErrSyntax) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("invalid INI syntax in %s on line %d: %s", e.File, e.Line, e.Source)
+	}
 	return fmt.Sprintf("invalid INI syntax on line %d: %s", e.Line, e.Source)
 }
 
+// ErrSyntaxList is returned when parsing with CollectErrors finds one or more syntax errors.
+type ErrSyntaxList []ErrSyntax
+
+// Renders one line per error, in the order of Errors.
+func (e ErrSyntaxList) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e.Errors() {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the errors sorted by line number, those in the input being parsed first and then those in each
+// included file by file name.
+func (e ErrSyntaxList) Errors() []ErrSyntax {
+	errs := append([]ErrSyntax(nil), e...)
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].File != errs[j].File {
+			return errs[i].File < errs[j].File
+		}
+		return errs[i].Line < errs[j].Line
+	})
+	return errs
+}
+
+// ParseOptions controls how INI data is parsed. The zero value parses exactly like Load.
+type ParseOptions struct {
+	// Called for each line that is neither a section header, a property, a comment nor blank. Returning
+	// nil skips the line and continues parsing, while returning an error stops parsing with that error.
+	// When nil, parsing stops with an ErrSyntax.
+	OnError func(line int, source string) error
+
+	// Keeps each occurrence of a repeated section header as a separate block, available through
+	// Decoder.SectionList after decoding and written back as separate blocks by passing that method as
+	// WriteOptions.Blocks. The File itself still holds the merged view of all blocks, with later blocks
+	// winning. Only a Decoder keeps the blocks, so decode with NewDecoder or NewFileDecoder; the Load
+	// functions drop them.
+	RepeatedSections bool
+
+	// Skips invalid lines and keeps parsing, returning every syntax error at the end as an ErrSyntaxList.
+	// Ignored when OnError is set.
+	CollectErrors bool
+
+	// Keeps section names and keys exactly as written instead of lowercasing them, which is the default.
+	CaseSensitive bool
+
+	// Lowercase keys, or section names, even when CaseSensitive is set, which then still applies to the
+	// other. They only matter together with CaseSensitive: without it keys and section names are both
+	// lowercased anyway, so ParseOptions{LowercaseKeys: true} alone lowercases section names too.
+	LowercaseKeys     bool
+	LowercaseSections bool
+
+	// Called for lines that parse but have unusual formatting, such as indentation mixing tabs and spaces,
+	// or an indented property that may have been meant as the continuation of the previous value in a
+	// stricter INI dialect. Warnings are advisory and never change how the line is parsed.
+	WarnFunc func(line int, msg string)
+
+	// Records the byte range each section occupies in the input, available through Decoder.SectionRange
+	// after decoding with NewDecoder or NewFileDecoder.
+	TrackOffsets bool
+
+	// Collects comment lines, available through Decoder.Comment after decoding with NewDecoder or
+	// NewFileDecoder. Consecutive comments are attached to the property that follows them, while comments
+	// with no property after them in their section, such as those just before the next header, are
+	// attached to the section with an empty key.
+	CollectComments bool
+
+	// Treats a ";" or "#" preceded by whitespace as the start of a comment running to the end of the line,
+	// on property lines and section headers alike, so "[server] ; production" declares [server]. Note that
+	// this also cuts values such as "color = #fff" short. Without it such lines are an error or are taken
+	// literally.
+	InlineComments bool
+
+	// Treats an empty section header such as "[]" or "[ ]" as a syntax error instead of switching back to
+	// the default section.
+	RejectEmptySection bool
+
+	// Removes double or single quotes wrapping a whole value, keeping any whitespace inside them.
+	// Within double quotes \" and \\ are unescaped, while single quotes keep their contents literally.
+	// Comment markers inside quotes do not start an inline comment. This reads the quoting produced by
+	// WriteOptions.QuoteValues. Values that do not start and end with the same quote are taken literally.
+	Quotes bool
+
+	// The section that properties before the first header, or after an empty "[]" header, are stored in.
+	// Empty means the default section "".
+	DefaultSectionName string
+
+	// Limits for untrusted input. Parsing stops with an error naming the limit and the line once a File
+	// would hold more than MaxSections sections, counting the default section once it has keys, or a
+	// section more than MaxKeysPerSection keys. Zero means unlimited.
+	MaxSections       int
+	MaxKeysPerSection int
+
+	// Validate each section name and key, after any lowercasing. A non-nil error stops parsing and is
+	// returned wrapped with the line number.
+	SectionNameFunc func(name string) error
+	KeyNameFunc     func(key string) error
+
+	// Called with the whole File once parsing has completed without error, for checks spanning several
+	// sections, such as requiring [tls] cert whenever [tls] exists. A non-nil error is returned from
+	// the load, but the parsed data is kept, so LoadWith returns the File alongside the error. When
+	// loading into a File that already holds data, Validate sees the merged result.
+	Validate func(File) error
+
+	// Enables raw sections for opaque multi-line data such as PEM certificates. A header ending in
+	// "|raw", like "[cert|raw]", declares the section "cert", and every following line up to the next
+	// section header is kept verbatim, comments and blank lines included, as the value of the RawKey
+	// key, with lines joined by "\n" and trailing blank lines removed.
+	RawSections bool
+
+	// Unescapes section names, so "\]" stands for "]" and "\\" for a backslash, and "[a\]b]" declares the
+	// section "a]b". This reads the names produced by WriteOptions.EscapeSectionNames.
+	EscapedSectionNames bool
+
+	// Removes a single matching pair of double or single quotes around a value, without the escape
+	// handling of Quotes. Whitespace inside the quotes is kept, as it is only trimmed outside them.
+	// Ignored when Quotes is set.
+	StripQuotes bool
+
+	// Accepts a tab as the delimiter between key and value, as in tab-separated exports. A property is
+	// split at the first "=" or tab, whichever comes first, and later tabs are kept in the value.
+	TabDelimiter bool
+
+	// Treats indented lines following a property as continuations of its value, joined to it with "\n",
+	// for files written in a YAML-like style. An indented line continues the value unless it is blank, a
+	// comment, or itself a property or section header; a blank line ends the value. An indented line
+	// with no property before it is still a syntax error.
+	IndentContinuation bool
+
+	// Replaces values of the form "@file:path", such as "cert = @file:/etc/ssl/cert.pem", with the
+	// contents of the named file at load time, minus one trailing line break. Relative paths are
+	// resolved against the working directory. A file that cannot be read stops parsing with an error
+	// naming the key and the path. When off, such values are kept literally.
+	FileDirective bool
+
+	// Enables "@include path" lines, which read the named file in place, starting in the default
+	// section and returning to the including section afterwards. Relative paths are resolved against
+	// the directory of the including file, or the working directory for input that was not loaded by
+	// name with LoadFileWith. Byte offsets and comments are only tracked for the top-level input: the
+	// range of a section spans any "@include" lines inside it, sections declared only in included
+	// files get no range, and comments in included files are not collected.
+	Include bool
+
+	// Limits how deeply includes may nest, so a long chain of distinct files fails with an error listing
+	// the chain. Zero means DefaultMaxIncludeDepth. Including a file that is already being read is caught
+	// separately as a cycle as soon as it happens, whatever the depth.
+	MaxIncludeDepth int
+
+	// Ignore the start of the input, for INI embedded after a "#!" line or front matter. The first
+	// SkipLines lines are dropped, and then, if StartMarker is set, every line up to and including the
+	// first one that is StartMarker after trimming, such as "---". Skipped lines are not parsed at all,
+	// but still count for line numbers. A StartMarker that never appears is an error. Includes are read
+	// in full.
+	SkipLines   int
+	StartMarker string
+
+	// Wraps the input before parsing, to decode legacy encodings such as GBK or Latin-1 into UTF-8. With
+	// golang.org/x/text it is typically
+	//
+	//	func(r io.Reader) io.Reader { return transform.NewReader(r, simplifiedchinese.GBK.NewDecoder()) }
+	//
+	// Nil reads the input as UTF-8. It is applied by the loaders; a Decoder reads its reader as given.
+	Transform func(io.Reader) io.Reader
+}
+
+// The key holding the contents of a raw section.
+const RawKey = "_raw"
+
 // A File represents a parsed INI file.
 type File map[string]Section
 
 // A Section represents a single section of an INI file.
 type Section map[string]string
 
+// Returns a new, empty File ready for use. Unlike a nil File, sections can be added to it directly.
+func New() File {
+	return make(File)
+}
+
+// Deletes every section of the File in place, so the same map can be reloaded without reallocating it.
+// Since a File is a map, every copy of it is emptied as well.
+func (f File) Reset() {
+	for name := range f {
+		delete(f, name)
+	}
+}
+
+// Reports whether the File has no sections at all, as for a nil File or one loaded from an empty
+// source. A File holding only empty sections is not empty, since its section headers were declared;
+// call Prune first to disregard them.
+func (f File) IsEmpty() bool {
+	return len(f) == 0
+}
+
 // Returns a named Section. A Section will be created if one does not already exist for the given name.
 func (f File) Section(name string) Section {
 	section := f[name]
@@ -44,12 +238,98 @@ func (f File) Section(name string) Section {
 	return section
 }
 
+// Calls fn for each section in sorted order of name, with the default section first, stopping early if
+// fn returns false. A nil File calls fn zero times.
+func (f File) Range(fn func(name string, s Section) bool) {
+	for _, name := range sortedSections(f) {
+		if !fn(name, f[name]) {
+			return
+		}
+	}
+}
+
+// Calls fn for every key in sorted order of section and key, and returns all the errors it reported
+// joined with errors.Join, each annotated with its section and key. It returns nil if fn never fails.
+func (f File) WalkErr(fn func(section, key, value string) error) error {
+	var errs []error
+	f.walkErr(fn, func(err error) bool {
+		errs = append(errs, err)
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// Works like WalkErr, but stops at the first error and returns it.
+func (f File) WalkErrFirst(fn func(section, key, value string) error) error {
+	var first error
+	f.walkErr(fn, func(err error) bool {
+		first = err
+		return false
+	})
+	return first
+}
+
+// Walks the File in sorted order, passing annotated errors from fn to report until it returns false.
+func (f File) walkErr(fn func(section, key, value string) error, report func(error) bool) {
+	for _, name := range sortedSections(f) {
+		section := f[name]
+		for _, key := range sortedKeys(section) {
+			if err := fn(name, key, section[key]); err != nil {
+				if !report(fmt.Errorf("section %q, key %q: %w", name, key, err)) {
+					return
+				}
+			}
+		}
+	}
+}
+
 // 根据名称返回Section，如果找不到则返回nil
 func (f File) GetSection(name string) Section {
 	section := f[name]
 	return section
 }
 
+// Returns a copy of the named section as a plain map, or an empty map if the section does not exist, so
+// the result can always be ranged over and changed without affecting the File.
+func (f File) GetStringMapString(section string) map[string]string {
+	if s := f.GetSection(section); s != nil {
+		return s.Copy()
+	}
+	return make(map[string]string)
+}
+
+// Returns the names of the sections matching a path.Match pattern such as "worker.*", in sorted order.
+// An invalid pattern returns path.ErrBadPattern.
+func (f File) MatchSections(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range sortedSections(f) {
+		if ok, _ := path.Match(pattern, name); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Returns the section for a named profile such as "prod", and whether it exists.
+func (f File) Profile(name string) (Section, bool) {
+	section, ok := f[name]
+	return section, ok
+}
+
+// Returns the profile section named by selectorKey in the default section, e.g. the [prod] section for
+// "profile = prod". If the key is missing, or names a section that does not exist, it returns nil and
+// false; callers wanting a fallback should check ok rather than rely on an empty Section.
+func (f File) ActiveProfile(selectorKey string) (Section, bool) {
+	name, ok := f.Get("", selectorKey)
+	if !ok {
+		return nil, false
+	}
+	return f.Profile(name)
+}
+
 type TimeMap map[int]string
 
 // 专用函数，用于统计section名称为纯数字的段落数量
@@ -73,16 +353,269 @@ func (f File) Get(section, key string) (value string, ok bool) {
 	return
 }
 
-// Loads INI data from a reader and stores the data in the File.
+// Looks up a key in a section, falling back to the same key in defaultSectionName, as with per-profile
+// sections inheriting from a shared [default]. A value in section wins even when empty, and ok is false
+// only when the key is in neither section. Unlike ResolveExtends, nothing is copied into the File.
+func (f File) GetWithDefaultSection(section, key, defaultSectionName string) (string, bool) {
+	if value, ok := f.Get(section, key); ok {
+		return value, true
+	}
+	return f.Get(defaultSectionName, key)
+}
+
+// Looks up the first of several candidate keys present in a section, as with Section.GetFirst.
+func (f File) GetFirst(section string, keys ...string) (value string, key string, ok bool) {
+	return f[section].GetFirst(keys...)
+}
+
+// Reports whether a key is present in a section.
+func (f File) HasKey(section, key string) bool {
+	_, ok := f.Get(section, key)
+	return ok
+}
+
+// Reports whether a value exists for a "section.key" reference, or a bare "key" in the default section.
+// The reference is split on its last ".", so section names may contain dots but keys may not; a dotted
+// key such as "db.host" in the default section cannot be addressed this way. Use HasKey or
+// Section.GetPath when the section and key are known separately.
+func (f File) Exists(ref string) bool {
+	section, key := "", ref
+	if i := strings.LastIndex(ref, "."); i >= 0 {
+		section, key = ref[:i], ref[i+1:]
+	}
+	return f.HasKey(section, key)
+}
+
+// Looks up a fully dotted path such as "server.tls.cert" in Files that use dotted section names and
+// keys. Every split of the path into a section name and a key is tried, starting with the longest
+// section name: "server.tls" / "cert", then "server" / "tls.cert", and finally the whole path as a key
+// of the default section. The first split naming an existing key wins.
+func (f File) Lookup(dottedPath string) (string, bool) {
+	for i := strings.LastIndex(dottedPath, "."); i >= 0; i = strings.LastIndex(dottedPath[:i], ".") {
+		if value, ok := f.Get(dottedPath[:i], dottedPath[i+1:]); ok {
+			return value, true
+		}
+	}
+	return f.Get("", dottedPath)
+}
+
+// Returns every (section, key) pair in the File, ordered by section name and then key. Keys of the
+// default section have an empty section name and so come first. A nil File yields an empty slice.
+func (f File) AllKeys() [][2]string {
+	pairs := [][2]string{}
+	for _, name := range sortedSections(f) {
+		for _, key := range sortedKeys(f[name]) {
+			pairs = append(pairs, [2]string{name, key})
+		}
+	}
+	return pairs
+}
+
+// Merges the sections and keys of other into the File. Values from other win when a key is present in both.
+func (f File) Merge(other File) {
+	f.MergeFunc(other, func(section, key, a, b string) string {
+		return b
+	})
+}
+
+// Merges the sections and keys of other into the File. For keys present in both files, resolve is called
+// with the File's value a and other's value b, and its return becomes the merged value. Keys unique to
+// either file are copied as-is.
+func (f File) MergeFunc(other File, resolve func(section, key, a, b string) string) {
+	for name, src := range other {
+		dst := f.Section(name)
+		for key, b := range src {
+			if a, ok := dst[key]; ok {
+				dst[key] = resolve(name, key, a, b)
+			} else {
+				dst[key] = b
+			}
+		}
+	}
+}
+
+// NormalizeOptions selects the transformations applied by File.Normalize.
+type NormalizeOptions struct {
+	LowercaseSections   bool // Lowercase section names
+	TrimKeys            bool // Trim whitespace around keys
+	TrimValues          bool // Trim whitespace around values
+	RemoveEmptySections bool // Delete sections without keys, including the default section
+}
+
+// Deletes every named section that has no keys, so they are not written as bare headers. The default
+// section is left alone even when empty, since it is never written with a header.
+func (f File) Prune() {
+	for name, section := range f {
+		if name != "" && len(section) == 0 {
+			delete(f, name)
+		}
+	}
+}
+
+// Canonicalizes the File in place. Transformations are applied in this order: section names are
+// lowercased, keys are trimmed, values are trimmed and finally empty sections are removed. When
+// lowercasing or trimming makes two names equal, their contents are merged in sorted order of the
+// original names, so the value from the name sorting last wins.
+func (f File) Normalize(opts NormalizeOptions) {
+	normalized := make(File, len(f))
+	for _, name := range sortedSections(f) {
+		section := f[name]
+		if opts.LowercaseSections {
+			name = strings.ToLower(name)
+		}
+		dst := normalized.Section(name)
+		for _, key := range sortedKeys(section) {
+			value := section[key]
+			if opts.TrimKeys {
+				key = strings.TrimSpace(key)
+			}
+			if opts.TrimValues {
+				value = strings.TrimSpace(value)
+			}
+			dst[key] = value
+		}
+	}
+	for name := range f {
+		delete(f, name)
+	}
+	for name, section := range normalized {
+		if opts.RemoveEmptySections && len(section) == 0 {
+			continue
+		}
+		f[name] = section
+	}
+}
+
+// Renames keys in every section according to mapping, from old key to new key, for schema migrations.
+// The value moves to the new key, overwriting any value already there, and the old key is deleted.
+// Renames are applied against the original keys at once, so they do not chain: with {"a": "b", "b": "c"}
+// the value of a ends up in b and the value of b in c. When several old keys map to the same new key,
+// the value of the old key sorting last wins.
+func (f File) RenameKeys(mapping map[string]string) {
+	for _, section := range f {
+		moved := make(Section)
+		for _, key := range sortedKeys(section) {
+			if newKey, ok := mapping[key]; ok && newKey != key {
+				moved[newKey] = section[key]
+				delete(section, key)
+			}
+		}
+		for key, value := range moved {
+			section[key] = value
+		}
+	}
+}
+
+// The key under which TemplateData stores the default section.
+const TemplateRootKey = "_root"
+
+// Returns the File as plain nested maps for text/template and html/template, so a value can be read as
+// {{ .section.key }}. The default section, whose empty name cannot be used that way, is stored under
+// TemplateRootKey instead and replaces any section actually named "_root". The maps are copies, so
+// changing them does not affect the File.
+func (f File) TemplateData() map[string]map[string]string {
+	data := make(map[string]map[string]string, len(f))
+	for name, section := range f {
+		if name == "" {
+			continue
+		}
+		data[name] = section.Copy()
+	}
+	if section, ok := f[""]; ok {
+		data[TemplateRootKey] = section.Copy()
+	}
+	return data
+}
+
+// Resolves section inheritance: every section with an extendsKey entry, such as "extends = base", gets
+// the keys of the named parent section that it does not define itself. Parents are resolved first, so
+// chains of any length work, and the child's own keys always win. The extendsKey entry is kept in each
+// child. An error is returned for a missing parent section or a cycle, in which case some sections may
+// already have been resolved.
+func (f File) ResolveExtends(extendsKey string) error {
+	resolved := make(map[string]bool)
+	var resolve func(name string, chain []string) error
+	resolve = func(name string, chain []string) error {
+		if resolved[name] {
+			return nil
+		}
+		for _, seen := range chain {
+			if seen == name {
+				return fmt.Errorf("cycle in %s: %s", extendsKey, strings.Join(append(chain, name), " -> "))
+			}
+		}
+		section := f[name]
+		if parentName, ok := section[extendsKey]; ok {
+			parent, ok := f[parentName]
+			if !ok {
+				return fmt.Errorf("section %q extends missing section %q", name, parentName)
+			}
+			if err := resolve(parentName, append(chain, name)); err != nil {
+				return err
+			}
+			for key, value := range parent {
+				if _, ok := section[key]; !ok {
+					section[key] = value
+				}
+			}
+		}
+		resolved[name] = true
+		return nil
+	}
+	for _, name := range sortedSections(f) {
+		if err := resolve(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A Conflict describes a key present in two Files with different values.
+type Conflict struct {
+	Section, Key string
+	Value        string // The value in the receiving File
+	Other        string // The value that Merge would replace it with
+}
+
+// Reports every key that Merge(other) would override with a different value, sorted by section and
+// key, without changing either File.
+func (f File) MergeConflicts(other File) []Conflict {
+	var conflicts []Conflict
+	for _, name := range sortedSections(other) {
+		section := f[name]
+		for _, key := range sortedKeys(other[name]) {
+			if value, ok := section[key]; ok && value != other[name][key] {
+				conflicts = append(conflicts, Conflict{name, key, value, other[name][key]})
+			}
+		}
+	}
+	return conflicts
+}
+
+// Loads INI data from a reader and stores the data in the File. Existing sections and keys are kept, so
+// loading several sources into the same File accumulates them, with values from the last load winning.
 func (f File) Load(in io.Reader) (err error) {
 	bufin, ok := in.(*bufio.Reader)
 	if !ok {
 		bufin = bufio.NewReader(in)
 	}
-	return parseFile(bufin, f)
+	return parseFile(bufin, f, ParseOptions{})
+}
+
+// Loads INI data from a reader using the given options and stores the data in the File. What a Decoder
+// records beyond the File, such as the blocks kept by RepeatedSections, is discarded.
+func (f File) LoadWith(in io.Reader, opts ParseOptions) error {
+	if opts.Transform != nil {
+		in = opts.Transform(in)
+	}
+	bufin, ok := in.(*bufio.Reader)
+	if !ok {
+		bufin = bufio.NewReader(in)
+	}
+	return parseFile(bufin, f, opts)
 }
 
-// Loads INI data from a named file and stores the data in the File.
+// Loads INI data from a named file and stores the data in the File, merging as with Load.
 func (f File) LoadFile(file string) (err error) {
 	in, err := os.Open(file)
 	if err != nil {
@@ -92,51 +625,102 @@ func (f File) LoadFile(file string) (err error) {
 	return f.Load(in)
 }
 
-// section, key 全部转小写返回
-func parseFile(in *bufio.Reader, file File) (err error) {
-	section := ""
-	lineNum := 0
-	for done := false; !done; {
-		var line string
-		if line, err = in.ReadString('\n'); err != nil {
-			if err == io.EOF {
-				done = true
-			} else {
-				return
-			}
-		}
-		lineNum++
-		line = strings.TrimSpace(line)
-		if len(line) == 0 {
-			// Skip blank lines
+// 按 opts 解析并存入 file。未设置 CaseSensitive 时 section 和 key 转小写，否则见 LowercaseKeys、LowercaseSections
+func parseFile(in *bufio.Reader, file File, opts ParseOptions) error {
+	d := NewDecoder(in)
+	d.SetOptions(opts)
+	return d.decodeInto(file)
+}
+
+// Splits a trimmed, non-comment line into the raw key and value of a property, or the raw name of a
+// section header with isSection set. ok is false if the line is neither. Properties take precedence
+// over section headers, and the key is everything before the first "=", which must not be empty.
+func splitLine(line string) (name, value string, isSection, ok bool) {
+	if i := strings.IndexByte(line, '='); i > 0 {
+		return line[:i], line[i+1:], false, true
+	}
+	if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") && len(line) >= 2 {
+		return line[1 : len(line)-1], "", true, true
+	}
+	return "", "", false, false
+}
+
+// Splits a line as splitLine does, but also accepts a tab as the delimiter of a property, so the key is
+// everything before the first "=" or tab.
+func splitLineTab(line string) (name, value string, isSection, ok bool) {
+	if i := strings.IndexAny(line, "=\t"); i > 0 {
+		return line[:i], line[i+1:], false, true
+	}
+	return splitLine(line)
+}
+
+// Loads and returns a File from a reader.
+func Load(in io.Reader) (File, error) {
+	file := make(File)
+	err := file.Load(in)
+	return file, err
+}
+
+// Loads and returns a File from several readers in order, so values from later readers override earlier
+// ones. Errors are annotated with the index of the failing reader and can be unwrapped to the
+// underlying error, such as an ErrSyntax. Nil readers are skipped.
+func LoadReaders(readers ...io.Reader) (File, error) {
+	file := make(File)
+	for i, in := range readers {
+		if in == nil {
 			continue
 		}
-		if line[0] == ';' || line[0] == '#' {
-			// Skip comments
-			continue
+		if err := file.Load(in); err != nil {
+			return file, fmt.Errorf("reader %d: %w", i, err)
 		}
+	}
+	return file, nil
+}
 
-		if groups := assignRegex.FindStringSubmatch(line); groups != nil {
-			key, val := groups[1], groups[2]
-			key, val = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(val)
-			file.Section(section)[key] = val
-		} else if groups := sectionRegex.FindStringSubmatch(line); groups != nil {
-			name := strings.ToLower(strings.TrimSpace(groups[1]))
-			section = name
-			// Create the section if it does not exist
-			file.Section(section)
-		} else {
-			return ErrSyntax{lineNum, line}
+// Loads and returns a File from a named file using the given options. Unlike LoadWith, includes in the
+// file are resolved relative to its directory. Blocks, offsets and comments recorded with
+// RepeatedSections, TrackOffsets or CollectComments are discarded; decode with NewFileDecoder to keep them.
+func LoadFileWith(filename string, opts ParseOptions) (File, error) {
+	file := make(File)
+	d, err := NewFileDecoder(filename, opts)
+	if err != nil {
+		return file, err
+	}
+	defer d.Close()
+	return file, d.decodeInto(file)
+}
+
+// Loads and returns a File from several named files in order, so values from later files override
+// earlier ones. Parse errors are annotated with the name of the failing file.
+func LoadFiles(filenames ...string) (File, error) {
+	file := make(File)
+	for _, filename := range filenames {
+		if err := loadFileInto(file, filename); err != nil {
+			return file, err
 		}
+	}
+	return file, nil
+}
 
+// Loads a named file into file, annotating parse errors with the file name. Errors opening the file
+// already name it.
+func loadFileInto(file File, filename string) error {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := file.Load(in); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
 	}
 	return nil
 }
 
-// Loads and returns a File from a reader.
-func Load(in io.Reader) (File, error) {
+// Loads and returns a File from a reader using the given options. As with File.LoadWith, use a Decoder
+// instead to keep blocks, offsets or comments.
+func LoadWith(in io.Reader, opts ParseOptions) (File, error) {
 	file := make(File)
-	err := file.Load(in)
+	err := file.LoadWith(in, opts)
 	return file, err
 }
 
@@ -147,6 +731,73 @@ func LoadFile(filename string) (File, error) {
 	return file, err
 }
 
+// Loads and returns an INI File from a file on disk, together with the file's FileInfo taken from the
+// same open handle, so callers can record its modification time without a separate os.Stat.
+func LoadFileStat(filename string) (File, os.FileInfo, error) {
+	file := make(File)
+	in, err := os.Open(filename)
+	if err != nil {
+		return file, nil, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return file, nil, err
+	}
+	err = file.Load(in)
+	return file, info, err
+}
+
+// Returns the names of the sections declared in a file, in order of first appearance, without keeping
+// any values. The default section is not included, even when declared with a "[]" header. Malformed
+// lines are reported as an ErrSyntax.
+func SectionNamesOf(filename string) ([]string, error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	d := NewDecoder(in)
+	names := []string{}
+	seen := make(map[string]bool)
+	for {
+		section, key, _, err := d.Next()
+		if err == io.EOF {
+			return names, nil
+		} else if err != nil {
+			return names, err
+		}
+		if key == "" && section != "" && !seen[section] {
+			seen[section] = true
+			names = append(names, section)
+		}
+	}
+}
+
+// Loads and returns an INI File from standard input, for use in command-line pipelines.
+func LoadStdin() (File, error) {
+	return Load(os.Stdin)
+}
+
+// Loads and returns an INI File from a gzip-compressed file on disk, such as "config.ini.gz".
+func LoadFileGzip(filename string) (File, error) {
+	file := make(File)
+	in, err := os.Open(filename)
+	if err != nil {
+		return file, err
+	}
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err == gzip.ErrHeader || err == io.EOF {
+		return file, fmt.Errorf("%s is not a gzip-compressed file", filename)
+	} else if err != nil {
+		return file, err
+	}
+	defer gz.Close()
+	err = file.Load(gz)
+	return file, err
+}
+
 // 专用函数，读取模型描述的信息
 func LoadModDesc(file string) (rst map[string]string, err error) {
 	rst = make(map[string]string)
@@ -156,12 +807,26 @@ func LoadModDesc(file string) (rst map[string]string, err error) {
 	}
 	defer in.Close()
 	bufin := bufio.NewReader(in)
-	err = parseFileDesc(bufin, rst)
+	err = parseFileDesc(bufin, rst, nil)
 	return
 }
 
-// 专用函数。只读描述section
-func parseFileDesc(in *bufio.Reader, descmap map[string]string) (err error) {
+// 专用函数，读取模型描述的信息，同时按顺序返回描述section中的注释行（去掉开头的;或#及空白）
+func LoadModDescWithComments(file string) (values map[string]string, comments []string, err error) {
+	values = make(map[string]string)
+	in, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+	bufin := bufio.NewReader(in)
+	comments = []string{}
+	err = parseFileDesc(bufin, values, &comments)
+	return
+}
+
+// 专用函数。只读描述section，comments不为nil时收集该section中的注释
+func parseFileDesc(in *bufio.Reader, descmap map[string]string, comments *[]string) (err error) {
 	found := false
 	lineNum := 0
 	for done := false; !done; {
@@ -181,6 +846,9 @@ func parseFileDesc(in *bufio.Reader, descmap map[string]string) (err error) {
 		}
 		if line[0] == ';' || line[0] == '#' {
 			// Skip comments
+			if found && comments != nil {
+				*comments = append(*comments, strings.TrimSpace(line[1:]))
+			}
 			continue
 		}
 
@@ -192,8 +860,7 @@ func parseFileDesc(in *bufio.Reader, descmap map[string]string) (err error) {
 			continue
 		}
 
-		if groups := assignRegex.FindStringSubmatch(line); groups != nil {
-			key, val := groups[1], groups[2]
+		if key, val, isSection, ok := splitLine(line); ok && !isSection {
 			key, val = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(val)
 			descmap[key] = val
 		} else {
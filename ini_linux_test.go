@@ -1,7 +1,10 @@
 package ini
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"syscall"
 	"testing"
 )
@@ -23,6 +26,53 @@ func TestLoadFile(t *testing.T) {
 	}
 }
 
+func TestIncludeClosesFiles(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.ini")
+	os.WriteFile(main, []byte("[a]\n@include nested.ini\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "nested.ini"), []byte("@include db.ini\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "db.ini"), []byte("[db]\nhost = x\n[other]\n"), 0644)
+	originalOpenFiles := numFilesOpen(t)
+
+	if _, err := LoadFileWith(main, ParseOptions{Include: true, MaxSections: 1}); err == nil {
+		t.Fatal("expected MaxSections to be exceeded")
+	}
+	if originalOpenFiles != numFilesOpen(t) {
+		t.Error("included files not closed after an error")
+	}
+
+	d := NewDecoder(strings.NewReader("@include " + main + "\n"))
+	d.SetOptions(ParseOptions{Include: true})
+	for i := 0; i < 2; i++ {
+		if _, _, _, err := d.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if originalOpenFiles == numFilesOpen(t) {
+		t.Fatal("expected included files to be open part-way through")
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if originalOpenFiles != numFilesOpen(t) {
+		t.Error("included files not closed by Close")
+	}
+
+	d, err := NewFileDecoder(main, ParseOptions{Include: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if originalOpenFiles != numFilesOpen(t) {
+		t.Error("file opened by NewFileDecoder not closed by Close")
+	}
+}
+
 func numFilesOpen(t *testing.T) (num uint64) {
 	var rlimit syscall.Rlimit
 	err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit)
@@ -1,9 +1,17 @@
 package ini
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"text/template"
 )
 
 func TestLoad(t *testing.T) {
@@ -87,3 +95,741 @@ func TestDefinedSectionBehaviour(t *testing.T) {
 		"a": {"this": "that"},
 	})
 }
+
+func TestMergeFunc(t *testing.T) {
+	base := File{
+		"":    {"name": "base"},
+		"foo": {"a": "short", "b": "keep"},
+	}
+	other := File{
+		"foo": {"a": "much longer", "c": "new"},
+		"bar": {"x": "y"},
+	}
+	base.MergeFunc(other, func(section, key, a, b string) string {
+		if len(a) >= len(b) {
+			return a
+		}
+		return b
+	})
+	expect := File{
+		"":    {"name": "base"},
+		"foo": {"a": "much longer", "b": "keep", "c": "new"},
+		"bar": {"x": "y"},
+	}
+	if !reflect.DeepEqual(base, expect) {
+		t.Errorf("expected %v, got %v", expect, base)
+	}
+
+	base.Merge(File{"foo": {"a": "last"}})
+	if value, _ := base.Get("foo", "a"); value != "last" {
+		t.Errorf("Merge: expected %q, got %q", "last", value)
+	}
+}
+
+func TestLoadTwice(t *testing.T) {
+	file := make(File)
+	if err := file.Load(strings.NewReader("a=1\n[foo]\nx=1\ny=1\n[bar]\nz=1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Load(strings.NewReader("[foo]\ny=2\n[baz]")); err != nil {
+		t.Fatal(err)
+	}
+	expect := File{
+		"":    {"a": "1"},
+		"foo": {"x": "1", "y": "2"},
+		"bar": {"z": "1"},
+		"baz": {},
+	}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+}
+
+func TestOnError(t *testing.T) {
+	src := "[foo]\nbar = baz\nwut?\nherp = derp\nstop!\nnever = reached"
+	var skipped []int
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{
+		OnError: func(line int, source string) error {
+			if source == "stop!" {
+				return ErrSyntax{Line: line, Source: source}
+			}
+			skipped = append(skipped, line)
+			return nil
+		},
+	})
+	if syntaxErr, ok := err.(ErrSyntax); !ok || syntaxErr.Line != 5 {
+		t.Fatalf("expected an ErrSyntax on line 5, got %v", err)
+	}
+	if !reflect.DeepEqual(skipped, []int{3}) {
+		t.Errorf("expected line 3 to be skipped, got %v", skipped)
+	}
+	if value, _ := file.Get("foo", "herp"); value != "derp" {
+		t.Errorf("expected parsing to continue after a skipped line, got %q", value)
+	}
+	if _, ok := file.Get("foo", "never"); ok {
+		t.Error("expected parsing to stop after the callback returned an error")
+	}
+}
+
+func TestExists(t *testing.T) {
+	file := File{
+		"":           {"debug": "true"},
+		"server":     {"port": "80"},
+		"worker.one": {"threads": "4"},
+	}
+	check := func(ref string, expect bool) {
+		if file.Exists(ref) != expect {
+			t.Errorf("Exists(%q): expected %v", ref, expect)
+		}
+	}
+	check("debug", true)
+	check("server.port", true)
+	check("worker.one.threads", true)
+	check("server.host", false)
+	check("missing.port", false)
+	check("port", false)
+}
+
+// Lines covering the edge cases of property and section header detection.
+var splitLineCases = []string{
+	"key=value",
+	"key = value",
+	"dsn = user=foo;pass=bar",
+	"multiple = equals = signs",
+	"empty =",
+	"=value",
+	"==",
+	"[section]",
+	"[ spaced section ]",
+	"[]",
+	"[a=b]",
+	"[nested [brackets]]",
+	"[unterminated",
+	"unterminated]",
+	"[",
+	"]",
+	"[]]",
+	"[[]",
+	"a\rb=c",
+	"wut?",
+}
+
+// The regular expressions the parser originally used to recognize properties and section headers.
+var (
+	assignRegex  = regexp.MustCompile(`^([^=]+)=(.*)$`)
+	sectionRegex = regexp.MustCompile(`^\[(.*)\]$`)
+)
+
+// Splits a line using only the regular expressions, as the parser originally did.
+func splitLineRegex(line string) (name, value string, isSection, ok bool) {
+	if groups := assignRegex.FindStringSubmatch(line); groups != nil {
+		return groups[1], groups[2], false, true
+	} else if groups := sectionRegex.FindStringSubmatch(line); groups != nil {
+		return groups[1], "", true, true
+	}
+	return "", "", false, false
+}
+
+func TestSplitLineParity(t *testing.T) {
+	for _, line := range splitLineCases {
+		name, value, isSection, ok := splitLine(line)
+		expectName, expectValue, expectSection, expectOk := splitLineRegex(line)
+		if name != expectName || value != expectValue || isSection != expectSection || ok != expectOk {
+			t.Errorf("splitLine(%q): expected %q, %q, %v, %v, got %q, %q, %v, %v", line,
+				expectName, expectValue, expectSection, expectOk, name, value, isSection, ok)
+		}
+	}
+}
+
+func BenchmarkSplitLine(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, line := range splitLineCases {
+			splitLine(line)
+		}
+	}
+}
+
+func BenchmarkSplitLineRegex(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, line := range splitLineCases {
+			splitLineRegex(line)
+		}
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	var src strings.Builder
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&src, "[section%d]\n", i)
+		for j := 0; j < 10; j++ {
+			fmt.Fprintf(&src, "key%d = value %d\n", j, j)
+		}
+	}
+	data := src.String()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Load(strings.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLoadMatchesRegexParser(t *testing.T) {
+	for _, line := range splitLineCases {
+		if strings.Contains(line, "\r") {
+			// Load ends lines at a lone "\r", unlike splitLine which only sees single lines
+			continue
+		}
+		file, err := Load(strings.NewReader(line))
+		if _, _, _, ok := splitLineRegex(line); ok != (err == nil) {
+			t.Errorf("Load(%q): unexpected error state %v", line, err)
+		}
+		if err != nil {
+			continue
+		}
+		name, value, isSection, _ := splitLineRegex(line)
+		expect := File{strings.ToLower(strings.TrimSpace(name)): {}}
+		if !isSection {
+			expect = File{"": {strings.ToLower(strings.TrimSpace(name)): strings.TrimSpace(value)}}
+		}
+		if !reflect.DeepEqual(file, expect) {
+			t.Errorf("Load(%q): expected %v, got %v", line, expect, file)
+		}
+	}
+}
+
+func TestLoadFileGzip(t *testing.T) {
+	dir := t.TempDir()
+	compressed := filepath.Join(dir, "config.ini.gz")
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("[default]\nstuff = things\n"))
+	gz.Close()
+	if err := os.WriteFile(compressed, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := LoadFileGzip(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(file, File{"default": {"stuff": "things"}}) {
+		t.Errorf("file not read correctly: %v", file)
+	}
+
+	plain := filepath.Join(dir, "config.ini")
+	if err := os.WriteFile(plain, []byte("[default]\nstuff = things\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFileGzip(plain); err == nil || !strings.Contains(err.Error(), "not a gzip") {
+		t.Errorf("expected a not-gzip error, got %v", err)
+	}
+}
+
+func TestMatchSections(t *testing.T) {
+	file := File{"worker.2": {}, "worker.1": {}, "worker": {}, "server": {}}
+	names, err := file.MatchSections("worker.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"worker.1", "worker.2"}; !reflect.DeepEqual(names, expect) {
+		t.Errorf("expected %v, got %v", expect, names)
+	}
+	if _, err := file.MatchSections("worker["); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestRepeatedSections(t *testing.T) {
+	src := "[server]\nhost = a\nport = 1\n[other]\nx = y\n[server]\nhost = b\n"
+	d, file := decodeWith(t, src, ParseOptions{RepeatedSections: true})
+	expect := []Section{{"host": "a", "port": "1"}, {"host": "b"}}
+	if blocks := d.SectionList("server"); !reflect.DeepEqual(blocks, expect) {
+		t.Errorf("expected %v, got %v", expect, blocks)
+	}
+	if value, _ := file.Get("server", "host"); value != "b" {
+		t.Errorf("expected the merged view to hold %q, got %q", "b", value)
+	}
+	if blocks := d.SectionList("other"); !reflect.DeepEqual(blocks, []Section{{"x": "y"}}) {
+		t.Errorf("expected a single block, got %v", blocks)
+	}
+	if blocks := d.SectionList("missing"); blocks != nil {
+		t.Errorf("expected nil, got %v", blocks)
+	}
+
+	var buf bytes.Buffer
+	if err := file.WriteWith(&buf, WriteOptions{Blocks: d.SectionList}); err != nil {
+		t.Fatal(err)
+	}
+	expectOut := "[other]\nx = y\n\n[server]\nhost = a\nport = 1\n\n[server]\nhost = b\n"
+	if buf.String() != expectOut {
+		t.Errorf("expected %q, got %q", expectOut, buf.String())
+	}
+
+	merged, _ := decodeWith(t, src, ParseOptions{})
+	if blocks := merged.SectionList("server"); blocks != nil {
+		t.Errorf("expected repeated sections to merge by default, got %v", blocks)
+	}
+}
+
+func TestRepeatedSectionsEdited(t *testing.T) {
+	d, file := decodeWith(t, "[s]\na = 1\n[s]\nb = 2\n[t]\nx = 1\n[t]\ny = 2\n", ParseOptions{RepeatedSections: true})
+	file["s"]["c"] = "3"
+	delete(file["s"], "a")
+	var buf bytes.Buffer
+	if err := file.WriteWith(&buf, WriteOptions{Blocks: d.SectionList}); err != nil {
+		t.Fatal(err)
+	}
+	expect := "[s]\nb = 2\nc = 3\n\n[t]\nx = 1\n\n[t]\ny = 2\n"
+	if buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+
+	file.RenameKeys(map[string]string{"x": "z"})
+	buf.Reset()
+	if err := file.WriteWith(&buf, WriteOptions{Blocks: d.SectionList}); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "[s]\nb = 2\nc = 3\n\n[t]\ny = 2\nz = 1\n"; buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	src := "[foo]\nwut?\nbar = baz\n=\nherp = derp\n[oops"
+	file, err := LoadWith(strings.NewReader(src), ParseOptions{CollectErrors: true})
+	list, ok := err.(ErrSyntaxList)
+	if !ok {
+		t.Fatalf("expected an ErrSyntaxList, got %v", err)
+	}
+	expect := []ErrSyntax{{Line: 2, Source: "wut?"}, {Line: 4, Source: "="}, {Line: 6, Source: "[oops"}}
+	if !reflect.DeepEqual(list.Errors(), expect) {
+		t.Errorf("expected %v, got %v", expect, list.Errors())
+	}
+	expectMsg := "invalid INI syntax on line 2: wut?\ninvalid INI syntax on line 4: =\ninvalid INI syntax on line 6: [oops"
+	if list.Error() != expectMsg {
+		t.Errorf("expected %q, got %q", expectMsg, list.Error())
+	}
+	if value, _ := file.Get("foo", "herp"); value != "derp" {
+		t.Error("expected parsing to continue past errors")
+	}
+	if unsorted := (ErrSyntaxList{{Line: 9, Source: "b"}, {Line: 3, Source: "a"}}); unsorted.Errors()[0].Line != 3 {
+		t.Error("expected Errors to sort by line number")
+	}
+}
+
+func TestCaseOptions(t *testing.T) {
+	src := "[Server]\nHost = a"
+	check := func(opts ParseOptions, expect File) {
+		file, err := LoadWith(strings.NewReader(src), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(file, expect) {
+			t.Errorf("%+v: expected %v, got %v", opts, expect, file)
+		}
+	}
+	check(ParseOptions{}, File{"server": {"host": "a"}})
+	check(ParseOptions{CaseSensitive: true}, File{"Server": {"Host": "a"}})
+	check(ParseOptions{CaseSensitive: true, LowercaseKeys: true}, File{"Server": {"host": "a"}})
+	check(ParseOptions{CaseSensitive: true, LowercaseSections: true}, File{"server": {"Host": "a"}})
+	check(ParseOptions{LowercaseKeys: true}, File{"server": {"host": "a"}})
+}
+
+func TestEqualsInValue(t *testing.T) {
+	src := "dsn = user=foo;pass=bar\nkey=a=b\ntrailing = x=\nonly = ==\n"
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{"": {
+		"dsn":      "user=foo;pass=bar",
+		"key":      "a=b",
+		"trailing": "x=",
+		"only":     "==",
+	}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reloaded, expect) {
+		t.Errorf("round trip: expected %v, got %v", expect, reloaded)
+	}
+}
+
+func TestLoadReaders(t *testing.T) {
+	file, err := LoadReaders(
+		strings.NewReader("[app]\nport = 80\nhost = localhost"),
+		nil,
+		strings.NewReader("[app]\nport = 8080"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := (File{"app": {"port": "8080", "host": "localhost"}}); !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+
+	_, err = LoadReaders(strings.NewReader("a = b"), strings.NewReader("\nwut?"))
+	var syntaxErr ErrSyntax
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 2 {
+		t.Fatalf("expected a wrapped ErrSyntax, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "reader 1: ") {
+		t.Errorf("expected the reader index in %q", err.Error())
+	}
+}
+
+func TestRange(t *testing.T) {
+	file := File{"c": {}, "": {}, "a": {}, "b": {}}
+	var names []string
+	file.Range(func(name string, s Section) bool {
+		names = append(names, name)
+		return name != "b"
+	})
+	if expect := []string{"", "a", "b"}; !reflect.DeepEqual(names, expect) {
+		t.Errorf("expected %q, got %q", expect, names)
+	}
+	File(nil).Range(func(name string, s Section) bool {
+		t.Error("expected a nil File to range zero times")
+		return true
+	})
+}
+
+func TestActiveProfile(t *testing.T) {
+	file, err := Load(strings.NewReader("profile = prod\nbroken = qa\n[dev]\nhost = localhost\n[prod]\nhost = example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if section, ok := file.ActiveProfile("profile"); !ok || section["host"] != "example.com" {
+		t.Errorf("expected the prod profile, got %v, %v", section, ok)
+	}
+	if section, ok := file.ActiveProfile("broken"); ok || section != nil {
+		t.Errorf("expected a missing profile, got %v, %v", section, ok)
+	}
+	if _, ok := file.ActiveProfile("missing"); ok {
+		t.Error("expected a missing selector to return ok=false")
+	}
+	if _, ok := file.Profile("dev"); !ok {
+		t.Error("expected the dev profile to exist")
+	}
+}
+
+func TestResolveExtends(t *testing.T) {
+	file := File{
+		"base":    {"host": "localhost", "port": "80", "debug": "false"},
+		"staging": {"extends": "base", "host": "staging"},
+		"prod":    {"extends": "staging", "debug": "true"},
+	}
+	if err := file.ResolveExtends("extends"); err != nil {
+		t.Fatal(err)
+	}
+	expect := Section{"extends": "staging", "host": "staging", "port": "80", "debug": "true"}
+	if !reflect.DeepEqual(file["prod"], expect) {
+		t.Errorf("expected %v, got %v", expect, file["prod"])
+	}
+
+	cyclic := File{"a": {"extends": "b"}, "b": {"extends": "a"}}
+	if err := cyclic.ResolveExtends("extends"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+	missing := File{"a": {"extends": "nope"}}
+	if err := missing.ResolveExtends("extends"); err == nil {
+		t.Error("expected an error for a missing parent")
+	}
+}
+
+func TestTemplateData(t *testing.T) {
+	file := File{"": {"name": "app"}, "server": {"host": "example.com"}}
+	data := file.TemplateData()
+	tmpl := template.Must(template.New("").Parse("{{ ._root.name }}@{{ .server.host }}"))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "app@example.com" {
+		t.Errorf("expected %q, got %q", "app@example.com", buf.String())
+	}
+	data["server"]["host"] = "changed"
+	if file["server"]["host"] != "example.com" {
+		t.Error("expected TemplateData to return copies")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	input := func() File {
+		return File{
+			"Server": {" host ": " a ", "port": "80"},
+			"server": {"host": "b"},
+			"empty":  {},
+		}
+	}
+	file := input()
+	file.Normalize(NormalizeOptions{LowercaseSections: true, TrimKeys: true, TrimValues: true, RemoveEmptySections: true})
+	if expect := (File{"server": {"host": "b", "port": "80"}}); !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+
+	file = input()
+	file.Normalize(NormalizeOptions{TrimValues: true})
+	expect := File{
+		"Server": {" host ": "a", "port": "80"},
+		"server": {"host": "b"},
+		"empty":  {},
+	}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+}
+
+func TestLoadStdin(t *testing.T) {
+	in, err := os.Open("test.ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	stdin := os.Stdin
+	os.Stdin = in
+	defer func() { os.Stdin = stdin }()
+	file, err := LoadStdin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(file, File{"default": {"stuff": "things"}}) {
+		t.Errorf("stdin not read correctly: %v", file)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	file := File{
+		"":           {"app.name": "web"},
+		"server":     {"tls.cert": "short", "port": "80"},
+		"server.tls": {"cert": "long"},
+	}
+	check := func(path, expect string, expectOk bool) {
+		if value, ok := file.Lookup(path); value != expect || ok != expectOk {
+			t.Errorf("Lookup(%q): expected %q, %v, got %q, %v", path, expect, expectOk, value, ok)
+		}
+	}
+	check("server.tls.cert", "long", true)
+	check("server.port", "80", true)
+	check("app.name", "web", true)
+	check("server.tls.key", "", false)
+	check("nothing", "", false)
+}
+
+func TestPrune(t *testing.T) {
+	file := File{"": {}, "empty": {}, "full": {"a": "b"}}
+	file.Prune()
+	if expect := (File{"": {}, "full": {"a": "b"}}); !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+}
+
+func TestLoadFileStat(t *testing.T) {
+	file, info, err := LoadFileStat("test.ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(file, File{"default": {"stuff": "things"}}) {
+		t.Errorf("file not read correctly: %v", file)
+	}
+	stat, err := os.Stat("test.ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name() != "test.ini" || !info.ModTime().Equal(stat.ModTime()) || info.Size() != stat.Size() {
+		t.Errorf("unexpected FileInfo %v", info)
+	}
+	if _, info, err := LoadFileStat("missing.ini"); err == nil || info != nil {
+		t.Error("expected an error and no FileInfo for a missing file")
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	base := File{"b": {"x": "1", "y": "1"}, "a": {"k": "old", "same": "v"}}
+	other := File{"b": {"y": "2", "z": "3"}, "a": {"k": "new", "same": "v"}, "c": {"k": "v"}}
+	expect := []Conflict{{"a", "k", "old", "new"}, {"b", "y", "1", "2"}}
+	if conflicts := base.MergeConflicts(other); !reflect.DeepEqual(conflicts, expect) {
+		t.Errorf("expected %v, got %v", expect, conflicts)
+	}
+	if value, _ := base.Get("a", "k"); value != "old" {
+		t.Error("expected MergeConflicts not to modify the File")
+	}
+}
+
+func TestWalkErr(t *testing.T) {
+	file := File{"b": {"port": "x"}, "a": {"port": "80", "host": ""}}
+	errEmpty := errors.New("empty value")
+	var visited []string
+	check := func(section, key, value string) error {
+		visited = append(visited, section+"."+key)
+		if value == "" {
+			return errEmpty
+		}
+		if key == "port" && value == "x" {
+			return errors.New("not a number")
+		}
+		return nil
+	}
+	err := file.WalkErr(check)
+	expect := "section \"a\", key \"host\": empty value\nsection \"b\", key \"port\": not a number"
+	if err == nil || err.Error() != expect || !errors.Is(err, errEmpty) {
+		t.Errorf("expected %q, got %v", expect, err)
+	}
+	if !reflect.DeepEqual(visited, []string{"a.host", "a.port", "b.port"}) {
+		t.Errorf("expected sorted visiting order, got %v", visited)
+	}
+
+	visited = nil
+	err = file.WalkErrFirst(check)
+	if err == nil || err.Error() != `section "a", key "host": empty value` || len(visited) != 1 {
+		t.Errorf("expected to stop at the first error, got %v after %v", err, visited)
+	}
+	if err := (File{"a": {"k": "v"}}).WalkErr(check); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestLoadModDescWithComments(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "model.ini")
+	src := "; file header\n[Description]\n# 模型名称\nname = demo\n;version of the model\nversion = 2\n[other]\n; not included\nx = 1\n"
+	if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	values, comments, err := LoadModDescWithComments(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := map[string]string{"name": "demo", "version": "2"}; !reflect.DeepEqual(values, expect) {
+		t.Errorf("expected %v, got %v", expect, values)
+	}
+	if expect := []string{"模型名称", "version of the model"}; !reflect.DeepEqual(comments, expect) {
+		t.Errorf("expected %q, got %q", expect, comments)
+	}
+	plain, err := LoadModDesc(filename)
+	if err != nil || !reflect.DeepEqual(plain, values) {
+		t.Errorf("expected LoadModDesc to be unchanged, got %v, %v", plain, err)
+	}
+}
+
+func TestSectionNamesOf(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "big.ini")
+	if err := os.WriteFile(filename, []byte("top = 1\n[Zeta]\na = 1\n; [commented]\n[alpha]\n[]\nx = 1\n[zeta]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := SectionNamesOf(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"zeta", "alpha"}; !reflect.DeepEqual(names, expect) {
+		t.Errorf("expected %q, got %q", expect, names)
+	}
+	broken := filepath.Join(dir, "broken.ini")
+	if err := os.WriteFile(broken, []byte("[a]\nwut?\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SectionNamesOf(broken); err == nil {
+		t.Error("expected a syntax error")
+	} else if syntaxErr, ok := err.(ErrSyntax); !ok || syntaxErr.Line != 2 {
+		t.Errorf("expected an ErrSyntax on line 2, got %v", err)
+	}
+}
+
+func TestGetStringMapString(t *testing.T) {
+	file := File{"labels": {"env": "prod"}}
+	labels := file.GetStringMapString("labels")
+	labels["team"] = "core"
+	if len(file["labels"]) != 1 {
+		t.Error("expected a copy")
+	}
+	if m := file.GetStringMapString("missing"); m == nil || len(m) != 0 {
+		t.Errorf("expected an empty map, got %v", m)
+	}
+}
+
+func TestAllKeys(t *testing.T) {
+	file := File{"b": {"y": "1", "x": "2"}, "": {"root": "3"}, "a": {}}
+	expect := [][2]string{{"", "root"}, {"b", "x"}, {"b", "y"}}
+	if keys := file.AllKeys(); !reflect.DeepEqual(keys, expect) {
+		t.Errorf("expected %v, got %v", expect, keys)
+	}
+	if keys := File(nil).AllKeys(); keys == nil || len(keys) != 0 {
+		t.Errorf("expected an empty slice, got %v", keys)
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	if !New().IsEmpty() || !File(nil).IsEmpty() {
+		t.Error("expected new and nil Files to be empty")
+	}
+	file, _ := Load(strings.NewReader("; only a comment\n"))
+	if !file.IsEmpty() {
+		t.Error("expected a File without sections to be empty")
+	}
+	file, _ = Load(strings.NewReader("[bare]\n"))
+	if file.IsEmpty() {
+		t.Error("expected a File with an empty section not to be empty")
+	}
+	file.Prune()
+	if !file.IsEmpty() {
+		t.Error("expected a pruned File to be empty")
+	}
+}
+
+func TestRenameKeys(t *testing.T) {
+	file := File{
+		"":   {"a": "1", "b": "2", "keep": "3"},
+		"db": {"host": "h", "hostname": "old", "a": "4"},
+	}
+	file.RenameKeys(map[string]string{"a": "b", "b": "c", "host": "hostname", "keep": "keep"})
+	expect := File{
+		"":   {"b": "1", "c": "2", "keep": "3"},
+		"db": {"hostname": "h", "b": "4"},
+	}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+}
+
+func TestReset(t *testing.T) {
+	file := New()
+	if err := file.Load(strings.NewReader("a = 1\n[s]\nb = 2\n")); err != nil {
+		t.Fatal(err)
+	}
+	file.Reset()
+	if file == nil || !file.IsEmpty() {
+		t.Errorf("expected an empty non-nil File, got %v", file)
+	}
+	if err := file.Load(strings.NewReader("c = 3\n")); err != nil || !reflect.DeepEqual(file, File{"": {"c": "3"}}) {
+		t.Errorf("expected the File to be reusable, got %v, %v", file, err)
+	}
+}
+
+func TestGetWithDefaultSection(t *testing.T) {
+	file := File{
+		"default": {"region": "eu", "output": "json", "retries": "3"},
+		"prod":    {"region": "us", "output": ""},
+	}
+	for _, c := range []struct {
+		section, key, value string
+		ok                  bool
+	}{
+		{"prod", "region", "us", true},
+		{"prod", "output", "", true},
+		{"prod", "retries", "3", true},
+		{"missing", "region", "eu", true},
+		{"prod", "nowhere", "", false},
+	} {
+		if value, ok := file.GetWithDefaultSection(c.section, c.key, "default"); value != c.value || ok != c.ok {
+			t.Errorf("(%q, %q): expected %q, %v, got %q, %v", c.section, c.key, c.value, c.ok, value, ok)
+		}
+	}
+}
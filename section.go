@@ -0,0 +1,134 @@
+package ini
+
+import "strings"
+
+// Looks up a dotted key such as "db.pool.size". Dotted keys are stored literally, so this is the same as a
+// plain map lookup; it exists to pair with Nested.
+func (s Section) GetPath(path string) (string, bool) {
+	value, ok := s[path]
+	return value, ok
+}
+
+// Expands dotted keys into a nested map, so "db.pool.size = 10" becomes
+// {"db": {"pool": {"size": "10"}}}. Leaves are strings and branches are map[string]interface{}.
+// When a key is both a leaf and a branch (both "a" and "a.b" are present), the branch wins and the
+// leaf value is stored under the empty key inside it, i.e. {"a": {"": ..., "b": ...}}.
+func (s Section) Nested() map[string]interface{} {
+	root := make(map[string]interface{})
+	for _, key := range sortedKeys(s) {
+		parts := strings.Split(key, ".")
+		node := root
+		for _, part := range parts[:len(parts)-1] {
+			switch child := node[part].(type) {
+			case map[string]interface{}:
+				node = child
+			case string:
+				branch := map[string]interface{}{"": child}
+				node[part] = branch
+				node = branch
+			default:
+				branch := make(map[string]interface{})
+				node[part] = branch
+				node = branch
+			}
+		}
+		leaf := parts[len(parts)-1]
+		if branch, ok := node[leaf].(map[string]interface{}); ok {
+			branch[""] = s[key]
+		} else {
+			node[leaf] = s[key]
+		}
+	}
+	return root
+}
+
+// Copies the keys of other into the Section, overriding existing values. A nil other is a no-op.
+func (s Section) Merge(other Section) {
+	for key, value := range other {
+		s[key] = value
+	}
+}
+
+// Returns the keys starting with prefix followed by a ".", with that part removed, so "label.env" is
+// returned as "env" for the prefix "label". A prefix that already ends in "." is used as-is. The result
+// is a new map and is empty, not nil, when nothing matches.
+func (s Section) GetMapWithPrefix(prefix string) map[string]string {
+	if !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	result := make(map[string]string)
+	for key, value := range s {
+		if strings.HasPrefix(key, prefix) {
+			result[key[len(prefix):]] = value
+		}
+	}
+	return result
+}
+
+// Returns the value of the first of keys present in the Section, together with the key that matched,
+// which helps when reading settings that were renamed over time. ok is false only when none are present.
+func (s Section) GetFirst(keys ...string) (value string, key string, ok bool) {
+	for _, key = range keys {
+		if value, ok = s[key]; ok {
+			return value, key, true
+		}
+	}
+	return "", "", false
+}
+
+// Returns the value of key, or def if it is missing, for use inline in expressions.
+func (s Section) StringOr(key, def string) string {
+	if value, ok := s[key]; ok {
+		return value
+	}
+	return def
+}
+
+// Returns the value of key with prefix removed, for values such as "unit://name" whose prefix callers
+// always strip. A value without the prefix is returned unchanged with ok=true; ok is false only when
+// the key is missing.
+func (s Section) GetStringTrimPrefix(key, prefix string) (string, bool) {
+	value, ok := s[key]
+	return strings.TrimPrefix(value, prefix), ok
+}
+
+// Returns the value of key with suffix removed, as with GetStringTrimPrefix.
+func (s Section) GetStringTrimSuffix(key, suffix string) (string, bool) {
+	value, ok := s[key]
+	return strings.TrimSuffix(value, suffix), ok
+}
+
+// Returns the keys present in other but absent from the Section, sorted.
+func (s Section) MissingKeysFrom(other Section) []string {
+	missing := []string{}
+	for _, key := range sortedKeys(other) {
+		if _, ok := s[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// Returns the keys present in both the Section and other, sorted.
+func (s Section) CommonKeys(other Section) []string {
+	common := []string{}
+	for _, key := range sortedKeys(other) {
+		if _, ok := s[key]; ok {
+			common = append(common, key)
+		}
+	}
+	return common
+}
+
+// Returns a copy of the Section that can be changed without affecting the original. Copying a nil
+// Section returns nil.
+func (s Section) Copy() Section {
+	if s == nil {
+		return nil
+	}
+	c := make(Section, len(s))
+	for key, value := range s {
+		c[key] = value
+	}
+	return c
+}
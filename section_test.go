@@ -0,0 +1,125 @@
+package ini
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNested(t *testing.T) {
+	section := Section{
+		"db.pool.size": "10",
+		"db.pool":      "default",
+		"db.host":      "localhost",
+		"name":         "app",
+	}
+	if value, ok := section.GetPath("db.pool.size"); !ok || value != "10" {
+		t.Errorf("GetPath: expected %q, got %q", "10", value)
+	}
+	expect := map[string]interface{}{
+		"name": "app",
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"pool": map[string]interface{}{
+				"":     "default",
+				"size": "10",
+			},
+		},
+	}
+	if nested := section.Nested(); !reflect.DeepEqual(nested, expect) {
+		t.Errorf("expected %v, got %v", expect, nested)
+	}
+}
+
+func TestSectionMerge(t *testing.T) {
+	section := Section{"a": "1", "b": "1"}
+	section.Merge(Section{"b": "2", "c": "2"})
+	section.Merge(nil)
+	if expect := (Section{"a": "1", "b": "2", "c": "2"}); !reflect.DeepEqual(section, expect) {
+		t.Errorf("expected %v, got %v", expect, section)
+	}
+}
+
+func TestGetMapWithPrefix(t *testing.T) {
+	section := Section{"label.env": "prod", "label.team": "core", "label": "x", "labels.y": "z"}
+	expect := map[string]string{"env": "prod", "team": "core"}
+	for _, prefix := range []string{"label", "label."} {
+		if m := section.GetMapWithPrefix(prefix); !reflect.DeepEqual(m, expect) {
+			t.Errorf("GetMapWithPrefix(%q): expected %v, got %v", prefix, expect, m)
+		}
+	}
+	if m := section.GetMapWithPrefix("missing"); m == nil || len(m) != 0 {
+		t.Errorf("expected an empty map, got %v", m)
+	}
+}
+
+func TestGetFirst(t *testing.T) {
+	file := File{"net": {"timeout": "30", "retries": "3"}}
+	if value, key, ok := file.GetFirst("net", "timeout_ms", "timeout"); !ok || key != "timeout" || value != "30" {
+		t.Errorf("expected the fallback key, got %q, %q, %v", value, key, ok)
+	}
+	if value, key, ok := file.GetFirst("net", "retries", "timeout"); !ok || key != "retries" || value != "3" {
+		t.Errorf("expected the first key, got %q, %q, %v", value, key, ok)
+	}
+	if value, key, ok := file.GetFirst("net", "a", "b"); ok || key != "" || value != "" {
+		t.Errorf("expected no match, got %q, %q, %v", value, key, ok)
+	}
+}
+
+func TestStringOr(t *testing.T) {
+	file := File{"net": {"addr": ":9090", "empty": ""}}
+	if addr := file.GetStringOr("net", "addr", ":8080"); addr != ":9090" {
+		t.Errorf("expected %q, got %q", ":9090", addr)
+	}
+	if addr := file.GetStringOr("net", "missing", ":8080"); addr != ":8080" {
+		t.Errorf("expected the default, got %q", addr)
+	}
+	if value := file["net"].StringOr("empty", "def"); value != "" {
+		t.Errorf("expected an empty value to be returned as-is, got %q", value)
+	}
+	if value := file["none"].StringOr("x", "def"); value != "def" {
+		t.Errorf("expected the default for a missing section, got %q", value)
+	}
+}
+
+func TestKeySets(t *testing.T) {
+	dev := Section{"host": "localhost", "debug": "true"}
+	prod := Section{"host": "example.com", "tls": "on", "cache": "1m"}
+	if missing := dev.MissingKeysFrom(prod); !reflect.DeepEqual(missing, []string{"cache", "tls"}) {
+		t.Errorf("expected [cache tls], got %v", missing)
+	}
+	if common := dev.CommonKeys(prod); !reflect.DeepEqual(common, []string{"host"}) {
+		t.Errorf("expected [host], got %v", common)
+	}
+	if missing := prod.MissingKeysFrom(nil); len(missing) != 0 {
+		t.Errorf("expected no missing keys, got %v", missing)
+	}
+}
+
+func TestSectionCopy(t *testing.T) {
+	section := Section{"a": "1"}
+	c := section.Copy()
+	c["a"] = "2"
+	c["b"] = "3"
+	if !reflect.DeepEqual(section, Section{"a": "1"}) {
+		t.Errorf("expected the original to be unchanged, got %v", section)
+	}
+	if Section(nil).Copy() != nil {
+		t.Error("expected a nil Section to copy to nil")
+	}
+}
+
+func TestGetStringTrim(t *testing.T) {
+	s := Section{"unit": "unit://web", "plain": "web", "file": "app.service"}
+	if value, ok := s.GetStringTrimPrefix("unit", "unit://"); !ok || value != "web" {
+		t.Errorf("expected web, got %q, %v", value, ok)
+	}
+	if value, ok := s.GetStringTrimPrefix("plain", "unit://"); !ok || value != "web" {
+		t.Errorf("expected the value unchanged, got %q, %v", value, ok)
+	}
+	if value, ok := s.GetStringTrimSuffix("file", ".service"); !ok || value != "app" {
+		t.Errorf("expected app, got %q, %v", value, ok)
+	}
+	if _, ok := s.GetStringTrimPrefix("missing", "unit://"); ok {
+		t.Error("expected ok=false for a missing key")
+	}
+}
@@ -0,0 +1,55 @@
+package ini
+
+// A TrackedFile is a File layered from several sources that records which source set the final value of
+// each key, to answer where a value came from.
+type TrackedFile struct {
+	File
+	origins map[[2]string]string
+}
+
+// Returns a new, empty TrackedFile. The zero TrackedFile cannot be loaded into.
+func NewTrackedFile() TrackedFile {
+	return TrackedFile{File: make(File), origins: make(map[[2]string]string)}
+}
+
+// Loads and returns a TrackedFile from several named files in order, as with LoadFiles.
+func LoadFilesTracked(filenames ...string) (TrackedFile, error) {
+	tf := NewTrackedFile()
+	for _, filename := range filenames {
+		if err := tf.LoadFile(filename); err != nil {
+			return tf, err
+		}
+	}
+	return tf, nil
+}
+
+// Loads a named file over the TrackedFile, so its values override existing ones and become attributed
+// to filename. On error nothing from the file is merged.
+func (tf TrackedFile) LoadFile(filename string) error {
+	layer := make(File)
+	if err := loadFileInto(layer, filename); err != nil {
+		return err
+	}
+	tf.MergeFrom(layer, filename)
+	return nil
+}
+
+// Merges other over the TrackedFile as with File.Merge, attributing every key of other to origin.
+func (tf TrackedFile) MergeFrom(other File, origin string) {
+	tf.Merge(other)
+	for name, section := range other {
+		for key := range section {
+			tf.origins[[2]string{name, key}] = origin
+		}
+	}
+}
+
+// Returns the name of the source that set the current value of a key. ok is false if the key was not
+// set by any tracked source, for example when it was added to the File directly.
+func (tf TrackedFile) Origin(section, key string) (filename string, ok bool) {
+	if _, exists := tf.File[section][key]; !exists {
+		return "", false
+	}
+	filename, ok = tf.origins[[2]string{section, key}]
+	return
+}
@@ -0,0 +1,43 @@
+package ini
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrackedFile(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.ini")
+	local := filepath.Join(dir, "local.ini")
+	os.WriteFile(base, []byte("name = app\n[db]\nhost = localhost\nport = 5432\n"), 0644)
+	os.WriteFile(local, []byte("[db]\nhost = db.internal\n"), 0644)
+
+	tf, err := LoadFilesTracked(base, local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct{ section, key, origin string }{
+		{"", "name", base},
+		{"db", "host", local},
+		{"db", "port", base},
+	} {
+		if origin, ok := tf.Origin(c.section, c.key); !ok || origin != c.origin {
+			t.Errorf("Origin(%q, %q): expected %q, got %q, %v", c.section, c.key, c.origin, origin, ok)
+		}
+	}
+	if tf.File["db"]["host"] != "db.internal" {
+		t.Errorf("expected the later file to win, got %q", tf.File["db"]["host"])
+	}
+	tf.File["db"]["user"] = "admin"
+	if _, ok := tf.Origin("db", "user"); ok {
+		t.Error("expected no origin for a key set directly")
+	}
+
+	bad := filepath.Join(dir, "bad.ini")
+	os.WriteFile(bad, []byte("not a property\n"), 0644)
+	if err := tf.LoadFile(bad); err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("expected an error naming %s, got %v", bad, err)
+	}
+}
@@ -0,0 +1,165 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Stores the values of the Section in the struct pointed to by v. Each exported field is read from the
+// key named by its `ini:"name"` tag, or from its name in lowercase, matching the keys produced by the
+// parser; a tag of "-" skips the field. Strings, booleans (with GetBool spellings), integers, unsigned
+// integers, floats, time.Duration and []string (as with GetList) are supported. Fields without a key in
+// the Section are left unchanged and keys without a field are ignored.
+func (s Section) Unmarshal(v interface{}) error {
+	_, err := s.unmarshal(v)
+	return err
+}
+
+// Works like Unmarshal, but returns an error listing every key of the Section that has no corresponding
+// struct field, which usually means a typo in the configuration. All known fields are still stored.
+func (s Section) UnmarshalStrict(v interface{}) error {
+	used, err := s.unmarshal(v)
+	if err != nil {
+		return err
+	}
+	var unknown []string
+	for key := range s {
+		if !used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown keys: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Stores the blocks of a repeated section, as returned by Decoder.SectionList, in the slice pointed to by
+// out, one element per block in order, so several [server] blocks decode into a []Server. Elements may
+// be structs or pointers to structs and are decoded as with Section.Unmarshal. The slice is replaced,
+// so no blocks leave it empty.
+//
+// It takes the blocks, as in UnmarshalSections(d.SectionList("server"), &servers), instead of being a
+// File method taking a section name, since a File only holds the merged section and the blocks are
+// known to the Decoder alone.
+func UnmarshalSections(blocks []Section, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("ini: UnmarshalSections needs a non-nil pointer to a slice")
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("ini: UnmarshalSections needs a slice of structs, not %s", slice.Type())
+	}
+	result := reflect.MakeSlice(slice.Type(), len(blocks), len(blocks))
+	for i, block := range blocks {
+		elem := reflect.New(elemType)
+		if err := block.Unmarshal(elem.Interface()); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+		if isPtr {
+			result.Index(i).Set(elem)
+		} else {
+			result.Index(i).Set(elem.Elem())
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
+// Stores the Section in v and returns the set of keys that have a field.
+func (s Section) unmarshal(v interface{}) (map[string]bool, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("ini: Unmarshal needs a non-nil pointer to a struct")
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	used := make(map[string]bool)
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		key := strings.ToLower(field.Name)
+		if tag := field.Tag.Get("ini"); tag == "-" {
+			continue
+		} else if tag != "" {
+			key = tag
+		}
+		used[key] = true
+		value, ok := s[key]
+		if !ok {
+			continue
+		}
+		if err := setField(rv.Field(i), s, key, value); err != nil {
+			return nil, err
+		}
+	}
+	return used, nil
+}
+
+func setField(field reflect.Value, s Section, key, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return invalidValue("duration", key, value)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, ok := s.GetBool(key)
+		if !ok {
+			return invalidValue("bool", key, value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return invalidValue("int", key, value)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return invalidValue("uint", key, value)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return invalidValue("float", key, value)
+		}
+		field.SetFloat(n)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s for key %q", field.Type(), key)
+		}
+		list := s.GetList(key)
+		elems := reflect.MakeSlice(field.Type(), len(list), len(list))
+		for i, elem := range list {
+			elems.Index(i).SetString(elem)
+		}
+		field.Set(elems)
+	default:
+		return fmt.Errorf("unsupported field type %s for key %q", field.Type(), key)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type serverConfig struct {
+	Host    string
+	Port    uint16
+	TLS     bool          `ini:"tls"`
+	Timeout time.Duration `ini:"timeout"`
+	Ratio   float64
+	Tags    []string
+	Ignored string `ini:"-"`
+	secret  string
+}
+
+func TestUnmarshal(t *testing.T) {
+	section := Section{
+		"host": "example.com", "port": "8080", "tls": "yes", "timeout": "5s", "ratio": "0.5",
+		"tags": "a, b", "ignored": "x", "secret": "y", "extra": "z",
+	}
+	var cfg serverConfig
+	if err := section.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	expect := serverConfig{Host: "example.com", Port: 8080, TLS: true, Timeout: 5 * time.Second, Ratio: 0.5, Tags: []string{"a", "b"}}
+	if !reflect.DeepEqual(cfg, expect) {
+		t.Errorf("expected %+v, got %+v", expect, cfg)
+	}
+
+	if err := (Section{"port": "99999"}).Unmarshal(&cfg); err == nil || !strings.Contains(err.Error(), `"port"`) {
+		t.Errorf("expected an out of range error naming the key, got %v", err)
+	}
+	if err := section.Unmarshal(cfg); err == nil {
+		t.Error("expected an error for a non-pointer")
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	var cfg serverConfig
+	err := (Section{"host": "a", "prot": "80", "extra": "z"}).UnmarshalStrict(&cfg)
+	if err == nil || err.Error() != "unknown keys: extra, prot" {
+		t.Errorf("expected the unknown keys to be listed, got %v", err)
+	}
+	if cfg.Host != "a" {
+		t.Error("expected known fields to be stored")
+	}
+	if err := (Section{"host": "a", "tags": "x"}).UnmarshalStrict(&cfg); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestUnmarshalSections(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+	}
+	d := NewDecoder(strings.NewReader("[server]\nhost = a\nport = 80\n[other]\nx = 1\n[server]\nhost = b\n"))
+	d.SetOptions(ParseOptions{RepeatedSections: true})
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	blocks := d.SectionList("server")
+	var servers []server
+	if err := UnmarshalSections(blocks, &servers); err != nil {
+		t.Fatal(err)
+	}
+	if expect := []server{{"a", 80}, {"b", 0}}; !reflect.DeepEqual(servers, expect) {
+		t.Errorf("expected %v, got %v", expect, servers)
+	}
+	var ptrs []*server
+	if err := UnmarshalSections(blocks, &ptrs); err != nil || len(ptrs) != 2 || ptrs[1].Host != "b" {
+		t.Errorf("expected two pointers, got %v, %v", ptrs, err)
+	}
+	if err := UnmarshalSections(d.SectionList("missing"), &servers); err != nil || len(servers) != 0 {
+		t.Errorf("expected an empty slice, got %v, %v", servers, err)
+	}
+
+	var notSlice server
+	var notStructs []int
+	for _, out := range []interface{}{&notSlice, servers, &notStructs} {
+		if err := UnmarshalSections(blocks, out); err == nil {
+			t.Errorf("%T: expected an error", out)
+		}
+	}
+	blocks[0]["port"] = "x"
+	if err := UnmarshalSections(blocks, &servers); err == nil || !strings.Contains(err.Error(), "block 0") {
+		t.Errorf("expected an error naming the block, got %v", err)
+	}
+}
@@ -0,0 +1,726 @@
+package ini
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Multipliers for the size suffixes accepted by GetBytes, keyed by lowercase suffix. Single-letter
+// suffixes are binary, so "4M" is the same as "4MiB".
+var byteUnits = map[string]int64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kb": 1e3, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1e6, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1e9, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1e12, "tib": 1 << 40,
+	"p": 1 << 50, "pb": 1e15, "pib": 1 << 50,
+	"e": 1 << 60, "eb": 1e18, "eib": 1 << 60,
+}
+
+var (
+	binaryUnits  = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	decimalUnits = []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+// Looks up a boolean. "true", "yes", "on" and "1" are true and "false", "no", "off" and "0" are false,
+// ignoring case. Missing or unrecognized values return ok=false.
+func (s Section) GetBool(key string) (value bool, ok bool) {
+	switch strings.ToLower(s[key]) {
+	case "true", "yes", "on", "1":
+		return true, true
+	case "false", "no", "off", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// Looks up a boolean in a section, as with Section.GetBool.
+func (f File) GetBool(section, key string) (value bool, ok bool) {
+	return f[section].GetBool(key)
+}
+
+// Looks up a boolean that must be exactly "true" or "false". Unlike GetBool and strconv.ParseBool, no
+// other spelling or capitalization is accepted. Missing or non-matching values return ok=false.
+func (s Section) GetBoolStrict(key string) (value bool, ok bool) {
+	switch s[key] {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return false, false
+}
+
+// Reports whether a section is enabled by its "enabled" key, read with GetBool spellings. A section that
+// exists without an "enabled" key is enabled by default; a missing section is disabled, and so is one
+// whose "enabled" value is not a recognized boolean.
+func (f File) SectionEnabled(name string) bool {
+	section, ok := f[name]
+	if !ok {
+		return false
+	}
+	if _, present := section["enabled"]; !present {
+		return true
+	}
+	enabled, _ := section.GetBool("enabled")
+	return enabled
+}
+
+// Resolves an on/off state from a positive and an inverted flag, such as "enabled" and "disabled", read
+// with GetBool spellings. The enabled key wins when both are set, so "enabled = true" with
+// "disabled = true" is on. A key whose value is not a recognized boolean counts as absent, and ok is
+// false when neither key yields a state.
+func (s Section) GetEnabled(enabledKey, disabledKey string) (enabled bool, ok bool) {
+	if enabled, ok = s.GetBool(enabledKey); ok {
+		return enabled, true
+	}
+	if disabled, ok := s.GetBool(disabledKey); ok {
+		return !disabled, true
+	}
+	return false, false
+}
+
+// Looks up a strict boolean in a section, as with Section.GetBoolStrict.
+func (f File) GetBoolStrict(section, key string) (value bool, ok bool) {
+	return f[section].GetBoolStrict(key)
+}
+
+// Returns the value of key as an int, or an error naming the key and the raw value when it is missing
+// or not a valid integer.
+func (s Section) Int(key string) (int, error) {
+	value, err := s.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, invalidValue("int", key, value)
+	}
+	return n, nil
+}
+
+// Returns the value of key as a float64, or an error naming the key and the raw value when it is
+// missing or not a valid number.
+func (s Section) Float(key string) (float64, error) {
+	value, err := s.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, invalidValue("float", key, value)
+	}
+	return f, nil
+}
+
+// Returns the value of key as a boolean using the same spellings as GetBool, or an error naming the key
+// and the raw value when it is missing or not recognized.
+func (s Section) Bool(key string) (bool, error) {
+	value, err := s.lookup(key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := s.GetBool(key)
+	if !ok {
+		return false, invalidValue("bool", key, value)
+	}
+	return b, nil
+}
+
+// Returns the value of key as a time.Duration such as "1m30s", or an error naming the key and the raw
+// value when it is missing or not a valid duration.
+func (s Section) Duration(key string) (time.Duration, error) {
+	value, err := s.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, invalidValue("duration", key, value)
+	}
+	return d, nil
+}
+
+// Returns the value of a mandatory key, or an error naming the key when it is missing or empty.
+func (s Section) GetStringRequired(key string) (string, error) {
+	value, err := s.lookup(key)
+	if err == nil && value == "" {
+		err = fmt.Errorf("empty value for required key %q", key)
+	}
+	return value, err
+}
+
+// Returns the value of a mandatory key as an int, as with GetStringRequired and Int.
+func (s Section) GetIntRequired(key string) (int, error) {
+	if _, err := s.GetStringRequired(key); err != nil {
+		return 0, err
+	}
+	return s.Int(key)
+}
+
+// Returns the value of a mandatory key as a float64, as with GetStringRequired and Float.
+func (s Section) GetFloatRequired(key string) (float64, error) {
+	if _, err := s.GetStringRequired(key); err != nil {
+		return 0, err
+	}
+	return s.Float(key)
+}
+
+// Returns the value of a mandatory key as a boolean, as with GetStringRequired and Bool.
+func (s Section) GetBoolRequired(key string) (bool, error) {
+	if _, err := s.GetStringRequired(key); err != nil {
+		return false, err
+	}
+	return s.Bool(key)
+}
+
+// Returns the value of a mandatory key as a time.Duration, as with GetStringRequired and Duration.
+func (s Section) GetDurationRequired(key string) (time.Duration, error) {
+	if _, err := s.GetStringRequired(key); err != nil {
+		return 0, err
+	}
+	return s.Duration(key)
+}
+
+// Returns the value of a mandatory key in a section, as with Section.GetStringRequired, with errors
+// also naming the section.
+func (f File) GetStringRequired(section, key string) (string, error) {
+	value, err := f[section].GetStringRequired(key)
+	return value, inSection(section, err)
+}
+
+// Returns the value of a mandatory key in a section as an int, as with Section.GetIntRequired.
+func (f File) GetIntRequired(section, key string) (int, error) {
+	n, err := f[section].GetIntRequired(key)
+	return n, inSection(section, err)
+}
+
+// Returns the value of a mandatory key in a section as a float64, as with Section.GetFloatRequired.
+func (f File) GetFloatRequired(section, key string) (float64, error) {
+	n, err := f[section].GetFloatRequired(key)
+	return n, inSection(section, err)
+}
+
+// Returns the value of a mandatory key in a section as a boolean, as with Section.GetBoolRequired.
+func (f File) GetBoolRequired(section, key string) (bool, error) {
+	b, err := f[section].GetBoolRequired(key)
+	return b, inSection(section, err)
+}
+
+// Returns the value of a mandatory key in a section as a time.Duration, as with
+// Section.GetDurationRequired.
+func (f File) GetDurationRequired(section, key string) (time.Duration, error) {
+	d, err := f[section].GetDurationRequired(key)
+	return d, inSection(section, err)
+}
+
+// Annotates a non-nil error with the section it concerns.
+func inSection(section string, err error) error {
+	if err != nil {
+		return fmt.Errorf("section %q: %w", section, err)
+	}
+	return nil
+}
+
+// Looks up an integer in the given base, as strconv.ParseInt does. Base 0 detects the base from a
+// "0x", "0o" or "0b" prefix (or a leading "0" for octal), so "0xFF", "0o17" and "0b1010" are all accepted.
+// Missing or invalid values return ok=false.
+func (s Section) GetIntBase(key string, base int) (int64, bool) {
+	value, ok := s[key]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value, base, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// Looks up an integer in the given base in a section, as with Section.GetIntBase.
+func (f File) GetIntBase(section, key string, base int) (int64, bool) {
+	return f[section].GetIntBase(key, base)
+}
+
+// Returns the boolean value of a key, or def if it is missing or invalid.
+func (f File) Bool(section, key string, def bool) bool {
+	if b, err := f[section].Bool(key); err == nil {
+		return b
+	}
+	return def
+}
+
+// Returns the int value of a key, or def if it is missing or invalid.
+func (f File) Int(section, key string, def int) int {
+	if n, err := f[section].Int(key); err == nil {
+		return n
+	}
+	return def
+}
+
+// Returns the float64 value of a key, or def if it is missing or invalid.
+func (f File) Float(section, key string, def float64) float64 {
+	if n, err := f[section].Float(key); err == nil {
+		return n
+	}
+	return def
+}
+
+// Returns the value of a key, or def if it is missing.
+func (f File) Str(section, key string, def string) string {
+	return f[section].StringOr(key, def)
+}
+
+// Returns the value of a key, or def if it is missing, e.g. cfg.GetStringOr("net", "addr", ":8080").
+// Use Get when it matters whether the key is present.
+func (f File) GetStringOr(section, key, def string) string {
+	return f[section].StringOr(key, def)
+}
+
+// Returns the time.Duration value of a key, or def if it is missing or invalid.
+func (f File) Dur(section, key string, def time.Duration) time.Duration {
+	if d, err := f[section].Duration(key); err == nil {
+		return d
+	}
+	return def
+}
+
+func (s Section) lookup(key string) (string, error) {
+	value, ok := s[key]
+	if !ok {
+		return "", fmt.Errorf("missing key %q", key)
+	}
+	return value, nil
+}
+
+func invalidValue(kind, key, value string) error {
+	return fmt.Errorf("invalid %s value %q for key %q", kind, value, key)
+}
+
+// Looks up a size such as "512", "64KB", "1.5 GiB" or "4M" and returns it in bytes. KB, MB, ... are
+// 1000-based, while KiB, MiB, ... and the single-letter forms are 1024-based. Suffixes are
+// case-insensitive. Missing, malformed or out of range values return ok=false.
+func (s Section) GetBytes(key string) (int64, bool) {
+	value, ok := s[key]
+	if !ok {
+		return 0, false
+	}
+	return parseBytes(value)
+}
+
+// Looks up a list of sizes such as "1M,4M,16M", split on sep with each element parsed as by GetBytes. A
+// missing key or any invalid element returns ok=false; an empty value returns an empty list.
+func (s Section) GetBytesSlice(key, sep string) ([]int64, bool) {
+	value, ok := s[key]
+	if !ok {
+		return nil, false
+	}
+	sizes := []int64{}
+	if strings.TrimSpace(value) == "" {
+		return sizes, true
+	}
+	for _, elem := range strings.Split(value, sep) {
+		n, ok := parseBytes(elem)
+		if !ok {
+			return nil, false
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes, true
+}
+
+// Looks up a size in a section, as with Section.GetBytes.
+func (f File) GetBytes(section, key string) (int64, bool) {
+	return f[section].GetBytes(key)
+}
+
+// Stores n as a human-readable size formatted by FormatBytes.
+func (s Section) SetBytes(key string, n int64, binary bool) {
+	s[key] = FormatBytes(n, binary)
+}
+
+// Stores an integer in decimal.
+func (s Section) SetInt(key string, v int) {
+	s[key] = strconv.Itoa(v)
+}
+
+// Stores a boolean as "true" or "false".
+func (s Section) SetBool(key string, v bool) {
+	s[key] = strconv.FormatBool(v)
+}
+
+// Stores a float in the shortest form that reads back as exactly v, such as "0.1" or "1e+21".
+func (s Section) SetFloat64(key string, v float64) {
+	s[key] = strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Stores a duration in the form of time.Duration.String, such as "1m30s".
+func (s Section) SetDuration(key string, v time.Duration) {
+	s[key] = v.String()
+}
+
+// Stores an integer in a section, as with Section.SetInt, creating the section if needed.
+func (f File) SetInt(section, key string, v int) {
+	f.Section(section).SetInt(key, v)
+}
+
+// Stores a boolean in a section, as with Section.SetBool, creating the section if needed.
+func (f File) SetBool(section, key string, v bool) {
+	f.Section(section).SetBool(key, v)
+}
+
+// Stores a float in a section, as with Section.SetFloat64, creating the section if needed.
+func (f File) SetFloat64(section, key string, v float64) {
+	f.Section(section).SetFloat64(key, v)
+}
+
+// Stores a duration in a section, as with Section.SetDuration, creating the section if needed.
+func (f File) SetDuration(section, key string, v time.Duration) {
+	f.Section(section).SetDuration(key, v)
+}
+
+// Formats n bytes as a human-readable size, e.g. "4MiB" or "1025KiB". When binary is true the 1024-based
+// KiB, MiB, ... units are used, otherwise the 1000-based KB, MB, ... units. A size that is an exact
+// multiple of a unit is written as an integer in the largest such unit and read back losslessly by
+// GetBytes. Other sizes of at least one KiB or KB are rounded to two decimal places in the largest unit
+// not exceeding them, e.g. "1.5KiB".
+func FormatBytes(n int64, binary bool) string {
+	base, units := uint64(1000), decimalUnits
+	if binary {
+		base, units = 1024, binaryUnits
+	}
+	sign, u := "", uint64(n)
+	if n < 0 {
+		sign, u = "-", uint64(-(n+1))+1
+	}
+	i, mult := 0, uint64(1)
+	for i < len(units)-1 && u/mult >= base {
+		i++
+		mult *= base
+	}
+	j, exact := i, mult
+	for j > 0 && u%exact != 0 {
+		j--
+		exact /= base
+	}
+	if j > 0 || i == 0 {
+		return sign + strconv.FormatUint(u/exact, 10) + units[j]
+	}
+	num := strconv.FormatFloat(float64(u)/float64(mult), 'f', 2, 64)
+	num = strings.TrimRight(strings.TrimRight(num, "0"), ".")
+	return sign + num + units[i]
+}
+
+func parseBytes(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	num, unit := value, ""
+	if i := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	}); i >= 0 {
+		num, unit = value[:i], strings.TrimSpace(value[i:])
+	}
+	mult, ok := byteUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, false
+	}
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n > math.MaxInt64/mult || n < math.MinInt64/mult {
+			return 0, false
+		}
+		return n * mult, true
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, false
+	}
+	f = math.Round(f * float64(mult))
+	if f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// Looks up a list split on sep, with each element trimmed. Empty elements, as in "a, , b,", are kept
+// when keepEmpty is set and dropped otherwise. A missing key returns ok=false.
+func (s Section) GetSlice(key, sep string, keepEmpty bool) ([]string, bool) {
+	value, ok := s[key]
+	if !ok {
+		return nil, false
+	}
+	list := []string{}
+	for _, elem := range strings.Split(value, sep) {
+		if elem = strings.TrimSpace(elem); elem != "" || keepEmpty {
+			list = append(list, elem)
+		}
+	}
+	return list, true
+}
+
+// Looks up a list in a section, as with Section.GetSlice.
+func (f File) GetSlice(section, key, sep string, keepEmpty bool) ([]string, bool) {
+	return f[section].GetSlice(key, sep, keepEmpty)
+}
+
+// Looks up a value packed with sub-pairs, such as "k1=v1;k2=v2" with pairSep ";" and kvSep "=". Each pair
+// is split on the first kvSep and its key and value are trimmed. Pairs without kvSep, or with an empty
+// key, are skipped, and a later pair wins over an earlier one with the same key. A missing key returns
+// ok=false.
+func (s Section) GetSubMap(key, pairSep, kvSep string) (map[string]string, bool) {
+	value, ok := s[key]
+	if !ok {
+		return nil, false
+	}
+	m := make(map[string]string)
+	for _, pair := range strings.Split(value, pairSep) {
+		k, v, found := strings.Cut(pair, kvSep)
+		if k = strings.TrimSpace(k); found && k != "" {
+			m[k] = strings.TrimSpace(v)
+		}
+	}
+	return m, true
+}
+
+// Looks up a comma separated list such as "a, b, c". Elements may be wrapped in double quotes, inside
+// which commas and newlines are kept and a backslash escapes the next character, with "\n" standing for
+// a newline. Outside quotes only "\,", "\"" and "\\" are escapes and any other backslash is kept, so
+// paths such as "C:\dir" read back unchanged. Each element is trimmed and empty elements are dropped. A
+// missing key returns nil.
+func (s Section) GetList(key string) []string {
+	value, ok := s[key]
+	if !ok {
+		return nil
+	}
+	list := []string{}
+	var elem strings.Builder
+	flush := func() {
+		if e := strings.TrimSpace(elem.String()); e != "" {
+			list = append(list, e)
+		}
+		elem.Reset()
+	}
+	quoted, escaped := false, false
+	for _, r := range value {
+		switch {
+		case escaped:
+			if r == 'n' && quoted {
+				r = '\n'
+			} else if !quoted && r != ',' && r != '"' && r != '\\' {
+				elem.WriteByte('\\')
+			}
+			elem.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			flush()
+		default:
+			elem.WriteRune(r)
+		}
+	}
+	if escaped {
+		elem.WriteByte('\\')
+	}
+	flush()
+	return list
+}
+
+// Looks up a hex color in "#RRGGBB" or short "#RGB" form, ignoring case. Missing or malformed values
+// return ok=false. A "#" in a value is never treated as a comment, since comments must start a line.
+func (s Section) GetColor(key string) (r, g, b uint8, ok bool) {
+	value, ok := s[key]
+	if !ok || !strings.HasPrefix(value, "#") {
+		return 0, 0, 0, false
+	}
+	hex := value[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(n >> 16), uint8(n >> 8), uint8(n), true
+}
+
+// Looks up a single character such as a delimiter, as in "sep = ;". ok is false unless the value is
+// exactly one valid UTF-8 encoded rune. Since values are trimmed, a space or tab must be quoted and read
+// with ParseOptions.Quotes.
+func (s Section) GetRune(key string) (rune, bool) {
+	value, ok := s[key]
+	if !ok || utf8.RuneCountInString(value) != 1 {
+		return 0, false
+	}
+	if r, size := utf8.DecodeRuneInString(value); r != utf8.RuneError || size > 1 {
+		return r, true
+	}
+	return 0, false
+}
+
+// Looks up a single byte, as with GetRune. ok is false unless the value is exactly one byte long.
+func (s Section) GetByte(key string) (byte, bool) {
+	value, ok := s[key]
+	if !ok || len(value) != 1 {
+		return 0, false
+	}
+	return value[0], true
+}
+
+// Looks up a single character in a section, as with Section.GetRune.
+func (f File) GetRune(section, key string) (rune, bool) {
+	return f[section].GetRune(key)
+}
+
+// Looks up a single byte in a section, as with Section.GetByte.
+func (f File) GetByte(section, key string) (byte, bool) {
+	return f[section].GetByte(key)
+}
+
+// Looks up a list of durations such as "1s,2s,5s", split on sep with each element trimmed and parsed by
+// time.ParseDuration. A missing key or any invalid element returns ok=false rather than a partial list;
+// an empty value returns an empty list.
+func (s Section) GetDurationSlice(key, sep string) ([]time.Duration, bool) {
+	value, ok := s[key]
+	if !ok {
+		return nil, false
+	}
+	durations := []time.Duration{}
+	if strings.TrimSpace(value) == "" {
+		return durations, true
+	}
+	for _, elem := range strings.Split(value, sep) {
+		d, err := time.ParseDuration(strings.TrimSpace(elem))
+		if err != nil {
+			return nil, false
+		}
+		durations = append(durations, d)
+	}
+	return durations, true
+}
+
+// Parses the keys named in schema according to their declared type, one of "int", "bool", "float",
+// "duration" or "string", and returns the parsed values keyed by name. Keys missing from the Section
+// are left out of the result. An unknown type name or a value that fails to parse returns an error
+// naming the key.
+func (s Section) Typed(schema map[string]string) (map[string]any, error) {
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make(map[string]any, len(schema))
+	for _, key := range keys {
+		if _, ok := s[key]; !ok {
+			continue
+		}
+		var value any
+		var err error
+		switch schema[key] {
+		case "int":
+			value, err = s.Int(key)
+		case "bool":
+			value, err = s.Bool(key)
+		case "float":
+			value, err = s.Float(key)
+		case "duration":
+			value, err = s.Duration(key)
+		case "string":
+			value = s[key]
+		default:
+			err = fmt.Errorf("unknown type %q for key %q", schema[key], key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// Unmarshals the JSON-encoded value of key, such as `{"cpu":2,"mem":"1Gi"}`, into v. For a present key
+// it returns true along with any error from encoding/json; a missing key returns false and a nil error.
+// The value must reach the Section intact, so avoid InlineComments for keys holding JSON with " #" or
+// " ;" inside strings.
+func (s Section) GetJSON(key string, v interface{}) (bool, error) {
+	value, ok := s[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal([]byte(value), v)
+}
+
+// Looks up an arbitrarily large integer in the given base using big.Int.SetString. Base 0 detects the
+// base from a "0x", "0o" or "0b" prefix, and allows "_" between digits, as in Go literals. Missing or
+// invalid values return ok=false.
+func (s Section) GetBigInt(key string, base int) (*big.Int, bool) {
+	value, ok := s[key]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Int).SetString(value, base)
+}
+
+// Looks up a large integer in a section, as with Section.GetBigInt.
+func (f File) GetBigInt(section, key string, base int) (*big.Int, bool) {
+	return f[section].GetBigInt(key, base)
+}
+
+// Looks up an arbitrary-precision float using big.Float.SetString. Missing or invalid values return
+// ok=false.
+func (s Section) GetBigFloat(key string) (*big.Float, bool) {
+	value, ok := s[key]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Float).SetString(value)
+}
+
+// Looks up an arbitrary-precision float in a section, as with Section.GetBigFloat.
+func (f File) GetBigFloat(section, key string) (*big.Float, bool) {
+	return f[section].GetBigFloat(key)
+}
+
+// Looks up a duration range such as "100ms-500ms" or "30s - 2m". The value is split at the first "-" for
+// which both sides parse with time.ParseDuration, so negative bounds like "-1s-1s" work. A single
+// duration sets min and max to the same value. A missing key, an unparseable value or min greater than
+// max returns ok=false.
+func (s Section) GetDurationRange(key string) (min, max time.Duration, ok bool) {
+	value, ok := s[key]
+	if !ok {
+		return 0, 0, false
+	}
+	value = strings.TrimSpace(value)
+	for i := 1; i < len(value); i++ {
+		if value[i] != '-' {
+			continue
+		}
+		lo, err := time.ParseDuration(strings.TrimSpace(value[:i]))
+		if err != nil {
+			continue
+		}
+		hi, err := time.ParseDuration(strings.TrimSpace(value[i+1:]))
+		if err != nil {
+			continue
+		}
+		if lo > hi {
+			return 0, 0, false
+		}
+		return lo, hi, true
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, 0, false
+	}
+	return d, d, true
+}
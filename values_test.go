@@ -0,0 +1,447 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetBytes(t *testing.T) {
+	section := Section{
+		"plain":   "512",
+		"kb":      "64KB",
+		"kib":     "64 KiB",
+		"short":   "4M",
+		"decimal": "1.5GiB",
+		"bad":     "12 parsecs",
+	}
+	check := func(key string, expect int64, expectOk bool) {
+		if n, ok := section.GetBytes(key); n != expect || ok != expectOk {
+			t.Errorf("GetBytes(%q): expected %d, %v, got %d, %v", key, expect, expectOk, n, ok)
+		}
+	}
+	check("plain", 512, true)
+	check("kb", 64000, true)
+	check("kib", 64<<10, true)
+	check("short", 4<<20, true)
+	check("decimal", 3<<29, true)
+	check("bad", 0, false)
+	check("missing", 0, false)
+}
+
+func TestFormatBytes(t *testing.T) {
+	check := func(n int64, binary bool, expect string) {
+		if s := FormatBytes(n, binary); s != expect {
+			t.Errorf("FormatBytes(%d, %v): expected %q, got %q", n, binary, expect, s)
+		}
+	}
+	check(0, true, "0B")
+	check(1023, true, "1023B")
+	check(4<<20, true, "4MiB")
+	check(1536, true, "1.5KiB")
+	check(5e9, false, "5GB")
+	check(-2000, false, "-2KB")
+	check(1025<<10, true, "1025KiB")
+	check(1234e3, false, "1234KB")
+	check(1537, true, "1.5KiB")
+
+	section := make(Section)
+	roundTrip := func(n int64, binary bool) {
+		section.SetBytes("size", n, binary)
+		if got, ok := section.GetBytes("size"); !ok || got != n {
+			t.Errorf("round trip of %d via %q gave %d", n, section["size"], got)
+		}
+	}
+	for _, n := range []int64{1, 1 << 10, 3 << 30, -(1 << 40), 5 << 60, 1025 << 10, 1023, (1<<20 + 1) << 10} {
+		roundTrip(n, true)
+	}
+	for _, n := range []int64{1, 1e3, 7e12, -4e6, 9e18, 1234e3, 1001e6, -999} {
+		roundTrip(n, false)
+	}
+}
+
+func TestGetBool(t *testing.T) {
+	file := File{"flags": {
+		"a": "true", "b": "Yes", "c": "off", "d": "0", "e": "TRUE", "f": "t", "g": "maybe",
+	}}
+	check := func(get func(section, key string) (bool, bool), key string, expect, expectOk bool) {
+		if value, ok := get("flags", key); value != expect || ok != expectOk {
+			t.Errorf("%q: expected %v, %v, got %v, %v", key, expect, expectOk, value, ok)
+		}
+	}
+	check(file.GetBool, "a", true, true)
+	check(file.GetBool, "b", true, true)
+	check(file.GetBool, "c", false, true)
+	check(file.GetBool, "d", false, true)
+	check(file.GetBool, "g", false, false)
+	check(file.GetBool, "missing", false, false)
+
+	check(file.GetBoolStrict, "a", true, true)
+	check(file.GetBoolStrict, "b", false, false)
+	check(file.GetBoolStrict, "e", false, false)
+	check(file.GetBoolStrict, "f", false, false)
+	check(file.GetBoolStrict, "missing", false, false)
+}
+
+func TestGetList(t *testing.T) {
+	section := Section{
+		"plain":   "a, b,,c ,",
+		"quoted":  `"x, y", z`,
+		"escaped": `one\, two, "line\nbreak", say \"hi\"`,
+		"empty":   "",
+		"paths":   `C:\dir,D:\new, "E:\\x", \\srv\share\`,
+	}
+	check := func(key string, expect []string) {
+		if list := section.GetList(key); !reflect.DeepEqual(list, expect) {
+			t.Errorf("GetList(%q): expected %q, got %q", key, expect, list)
+		}
+	}
+	check("plain", []string{"a", "b", "c"})
+	check("quoted", []string{"x, y", "z"})
+	check("escaped", []string{"one, two", "line\nbreak", `say "hi"`})
+	check("empty", []string{})
+	check("paths", []string{`C:\dir`, `D:\new`, `E:\x`, `\srv\share\`})
+	check("missing", nil)
+}
+
+func TestErrorGetters(t *testing.T) {
+	section := Section{"port": "8080", "ratio": "0.5", "tls": "on", "timeout": "1m30s", "bad": "abc"}
+	if n, err := section.Int("port"); err != nil || n != 8080 {
+		t.Errorf("Int: got %d, %v", n, err)
+	}
+	if f, err := section.Float("ratio"); err != nil || f != 0.5 {
+		t.Errorf("Float: got %v, %v", f, err)
+	}
+	if b, err := section.Bool("tls"); err != nil || !b {
+		t.Errorf("Bool: got %v, %v", b, err)
+	}
+	if d, err := section.Duration("timeout"); err != nil || d != 90*time.Second {
+		t.Errorf("Duration: got %v, %v", d, err)
+	}
+	if _, err := section.Int("missing"); err == nil || err.Error() != `missing key "missing"` {
+		t.Errorf("expected a missing key error, got %v", err)
+	}
+	for _, get := range []func(string) error{
+		func(key string) error { _, err := section.Int(key); return err },
+		func(key string) error { _, err := section.Float(key); return err },
+		func(key string) error { _, err := section.Bool(key); return err },
+		func(key string) error { _, err := section.Duration(key); return err },
+	} {
+		if err := get("bad"); err == nil || !strings.Contains(err.Error(), `"abc" for key "bad"`) {
+			t.Errorf("expected an invalid value error naming the key and value, got %v", err)
+		}
+	}
+}
+
+func TestDefaultGetters(t *testing.T) {
+	file := File{"app": {"debug": "yes", "port": "8080", "ratio": "0.25", "name": "", "timeout": "5s", "bad": "x"}}
+	if !file.Bool("app", "debug", false) || file.Bool("app", "bad", false) || !file.Bool("nope", "x", true) {
+		t.Error("Bool returned the wrong value")
+	}
+	if file.Int("app", "port", 1) != 8080 || file.Int("app", "bad", 1) != 1 {
+		t.Error("Int returned the wrong value")
+	}
+	if file.Float("app", "ratio", 1) != 0.25 || file.Float("app", "missing", 1) != 1 {
+		t.Error("Float returned the wrong value")
+	}
+	if file.Str("app", "name", "def") != "" || file.Str("app", "missing", "def") != "def" {
+		t.Error("Str returned the wrong value")
+	}
+	if file.Dur("app", "timeout", time.Second) != 5*time.Second || file.Dur("app", "bad", time.Second) != time.Second {
+		t.Error("Dur returned the wrong value")
+	}
+}
+
+func TestGetIntBase(t *testing.T) {
+	file := File{"hw": {"mask": "0xFF", "mode": "0o17", "flags": "0b1010", "dec": "-42", "raw": "ff", "bad": "0xZZ"}}
+	check := func(key string, base int, expect int64, expectOk bool) {
+		if n, ok := file.GetIntBase("hw", key, base); n != expect || ok != expectOk {
+			t.Errorf("GetIntBase(%q, %d): expected %d, %v, got %d, %v", key, base, expect, expectOk, n, ok)
+		}
+	}
+	check("mask", 0, 255, true)
+	check("mode", 0, 15, true)
+	check("flags", 0, 10, true)
+	check("dec", 0, -42, true)
+	check("raw", 16, 255, true)
+	check("raw", 0, 0, false)
+	check("bad", 0, 0, false)
+	check("missing", 0, 0, false)
+}
+
+func TestGetColor(t *testing.T) {
+	file, err := Load(strings.NewReader("[theme]\nbg = #1e1e1E\nfg = #fa0\nbad = #12345\nword = #GGGGGG\nplain = 1e1e1e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	section := file["theme"]
+	check := func(key string, r, g, b uint8, expectOk bool) {
+		gr, gg, gb, ok := section.GetColor(key)
+		if gr != r || gg != g || gb != b || ok != expectOk {
+			t.Errorf("GetColor(%q): expected %d %d %d %v, got %d %d %d %v", key, r, g, b, expectOk, gr, gg, gb, ok)
+		}
+	}
+	check("bg", 0x1e, 0x1e, 0x1e, true)
+	check("fg", 0xff, 0xaa, 0x00, true)
+	check("bad", 0, 0, 0, false)
+	check("word", 0, 0, 0, false)
+	check("plain", 0, 0, 0, false)
+	check("missing", 0, 0, 0, false)
+}
+
+func TestGetDurationSlice(t *testing.T) {
+	section := Section{"backoff": "1s, 2s,500ms", "bad": "1s,soon", "empty": " ", "trailing": "1s,"}
+	if d, ok := section.GetDurationSlice("backoff", ","); !ok || !reflect.DeepEqual(d, []time.Duration{time.Second, 2 * time.Second, 500 * time.Millisecond}) {
+		t.Errorf("expected three durations, got %v, %v", d, ok)
+	}
+	if d, ok := section.GetDurationSlice("empty", ","); !ok || len(d) != 0 {
+		t.Errorf("expected an empty list, got %v, %v", d, ok)
+	}
+	for _, key := range []string{"bad", "trailing", "missing"} {
+		if d, ok := section.GetDurationSlice(key, ","); ok || d != nil {
+			t.Errorf("GetDurationSlice(%q): expected failure, got %v, %v", key, d, ok)
+		}
+	}
+}
+
+func TestTyped(t *testing.T) {
+	section := Section{"port": "80", "tls": "on", "ratio": "0.5", "timeout": "2s", "name": "web"}
+	values, err := section.Typed(map[string]string{
+		"port": "int", "tls": "bool", "ratio": "float", "timeout": "duration", "name": "string", "absent": "int",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]any{"port": 80, "tls": true, "ratio": 0.5, "timeout": 2 * time.Second, "name": "web"}
+	if !reflect.DeepEqual(values, expect) {
+		t.Errorf("expected %v, got %v", expect, values)
+	}
+	if _, err := section.Typed(map[string]string{"name": "int"}); err == nil || !strings.Contains(err.Error(), `"name"`) {
+		t.Errorf("expected a parse error naming the key, got %v", err)
+	}
+	if _, err := section.Typed(map[string]string{"port": "uint"}); err == nil || !strings.Contains(err.Error(), `"port"`) {
+		t.Errorf("expected an unknown type error naming the key, got %v", err)
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	file, err := Load(strings.NewReader(`limits = {"cpu":2,"mem":"1Gi"}` + "\nbad = {nope"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var limits struct {
+		CPU int    `json:"cpu"`
+		Mem string `json:"mem"`
+	}
+	if ok, err := file[""].GetJSON("limits", &limits); !ok || err != nil || limits.CPU != 2 || limits.Mem != "1Gi" {
+		t.Errorf("expected the JSON to be decoded, got %+v, %v, %v", limits, ok, err)
+	}
+	if ok, err := file[""].GetJSON("bad", &limits); !ok || err == nil {
+		t.Errorf("expected a decoding error, got %v, %v", ok, err)
+	}
+	if ok, err := file[""].GetJSON("missing", &limits); ok || err != nil {
+		t.Errorf("expected false and no error, got %v, %v", ok, err)
+	}
+}
+
+func TestSectionEnabled(t *testing.T) {
+	file := File{"a": {}, "b": {"enabled": "off"}, "c": {"enabled": "yes"}, "d": {"enabled": "perhaps"}}
+	for name, expect := range map[string]bool{"a": true, "b": false, "c": true, "d": false, "missing": false} {
+		if file.SectionEnabled(name) != expect {
+			t.Errorf("SectionEnabled(%q): expected %v", name, expect)
+		}
+	}
+}
+
+func TestGetBytesSlice(t *testing.T) {
+	section := Section{"sizes": "1M, 4M,16KB", "bad": "1M,lots", "empty": ""}
+	if sizes, ok := section.GetBytesSlice("sizes", ","); !ok || !reflect.DeepEqual(sizes, []int64{1 << 20, 4 << 20, 16000}) {
+		t.Errorf("expected three sizes, got %v, %v", sizes, ok)
+	}
+	if sizes, ok := section.GetBytesSlice("empty", ","); !ok || sizes == nil || len(sizes) != 0 {
+		t.Errorf("expected an empty list, got %v, %v", sizes, ok)
+	}
+	for _, key := range []string{"bad", "missing"} {
+		if sizes, ok := section.GetBytesSlice(key, ","); ok || sizes != nil {
+			t.Errorf("GetBytesSlice(%q): expected failure, got %v, %v", key, sizes, ok)
+		}
+	}
+}
+
+func TestGetSlice(t *testing.T) {
+	file := File{"a": {"tags": "a, , b,"}}
+	if list, ok := file.GetSlice("a", "tags", ",", false); !ok || !reflect.DeepEqual(list, []string{"a", "b"}) {
+		t.Errorf("expected empties dropped, got %q, %v", list, ok)
+	}
+	if list, ok := file.GetSlice("a", "tags", ",", true); !ok || !reflect.DeepEqual(list, []string{"a", "", "b", ""}) {
+		t.Errorf("expected empties kept, got %q, %v", list, ok)
+	}
+	if list, ok := file.GetSlice("a", "missing", ",", true); ok || list != nil {
+		t.Errorf("expected a missing key to fail, got %q, %v", list, ok)
+	}
+}
+
+func TestGetBig(t *testing.T) {
+	file := File{"crypto": {
+		"prime":    "170141183460469231731687303715884105727",
+		"hex":      "0xFFFFFFFFFFFFFFFFFFFF",
+		"avogadro": "6.02214076e23",
+		"bad":      "12ab",
+	}}
+	if n, ok := file.GetBigInt("crypto", "prime", 10); !ok || n.String() != "170141183460469231731687303715884105727" {
+		t.Errorf("unexpected big.Int %v, %v", n, ok)
+	}
+	if n, ok := file.GetBigInt("crypto", "hex", 0); !ok || n.Text(16) != "ffffffffffffffffffff" {
+		t.Errorf("unexpected big.Int %v, %v", n, ok)
+	}
+	if _, ok := file.GetBigInt("crypto", "bad", 10); ok {
+		t.Error("expected an invalid integer to fail")
+	}
+	if f, ok := file.GetBigFloat("crypto", "avogadro"); !ok || f.Text('e', 8) != "6.02214076e+23" {
+		t.Errorf("unexpected big.Float %v, %v", f, ok)
+	}
+	if _, ok := file.GetBigFloat("crypto", "missing"); ok {
+		t.Error("expected a missing key to fail")
+	}
+}
+
+func TestGetDurationRange(t *testing.T) {
+	section := Section{
+		"delay": "100ms-500ms", "spaced": "30s - 2m", "negative": "-1s--500ms", "single": "5s",
+		"reversed": "2s-1s", "bad": "soon-later",
+	}
+	check := func(key string, min, max time.Duration, expectOk bool) {
+		lo, hi, ok := section.GetDurationRange(key)
+		if lo != min || hi != max || ok != expectOk {
+			t.Errorf("GetDurationRange(%q): expected %v, %v, %v, got %v, %v, %v", key, min, max, expectOk, lo, hi, ok)
+		}
+	}
+	check("delay", 100*time.Millisecond, 500*time.Millisecond, true)
+	check("spaced", 30*time.Second, 2*time.Minute, true)
+	check("negative", -time.Second, -500*time.Millisecond, true)
+	check("single", 5*time.Second, 5*time.Second, true)
+	check("reversed", 0, 0, false)
+	check("bad", 0, 0, false)
+	check("missing", 0, 0, false)
+}
+
+func TestGetSubMap(t *testing.T) {
+	s := Section{"opts": "sslmode=disable; timeout = 5s;;broken;url=a=b", "empty": ""}
+	m, ok := s.GetSubMap("opts", ";", "=")
+	expect := map[string]string{"sslmode": "disable", "timeout": "5s", "url": "a=b"}
+	if !ok || !reflect.DeepEqual(m, expect) {
+		t.Errorf("expected %v, got %v, %v", expect, m, ok)
+	}
+	if m, ok := s.GetSubMap("empty", ";", "="); !ok || len(m) != 0 {
+		t.Errorf("expected an empty map, got %v, %v", m, ok)
+	}
+	if _, ok := s.GetSubMap("missing", ";", "="); ok {
+		t.Error("expected ok=false for a missing key")
+	}
+}
+
+func TestTypedSetters(t *testing.T) {
+	file := make(File)
+	file.SetInt("server", "port", -8080)
+	file.SetBool("server", "tls", true)
+	file.SetFloat64("server", "ratio", 0.1)
+	file.SetDuration("server", "timeout", 90*time.Second)
+	expect := File{"server": {"port": "-8080", "tls": "true", "ratio": "0.1", "timeout": "1m30s"}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Errorf("expected %v, got %v", expect, file)
+	}
+	s := file["server"]
+	if v, err := s.Int("port"); err != nil || v != -8080 {
+		t.Errorf("expected -8080, got %v, %v", v, err)
+	}
+	if v, err := s.Float("ratio"); err != nil || v != 0.1 {
+		t.Errorf("expected 0.1, got %v, %v", v, err)
+	}
+	if v, err := s.Duration("timeout"); err != nil || v != 90*time.Second {
+		t.Errorf("expected 1m30s, got %v, %v", v, err)
+	}
+}
+
+func TestGetRune(t *testing.T) {
+	s := Section{"sep": ";", "arrow": "→", "long": "ab", "empty": "", "invalid": "\xff"}
+	for _, c := range []struct {
+		key    string
+		rune   rune
+		runeOk bool
+		byteOk bool
+	}{
+		{"sep", ';', true, true},
+		{"arrow", '→', true, false},
+		{"long", 0, false, false},
+		{"empty", 0, false, false},
+		{"invalid", 0, false, true},
+		{"missing", 0, false, false},
+	} {
+		if r, ok := s.GetRune(c.key); r != c.rune || ok != c.runeOk {
+			t.Errorf("GetRune(%q): expected %q, %v, got %q, %v", c.key, c.rune, c.runeOk, r, ok)
+		}
+		if _, ok := s.GetByte(c.key); ok != c.byteOk {
+			t.Errorf("GetByte(%q): expected ok=%v", c.key, c.byteOk)
+		}
+	}
+	if b, ok := (File{"csv": s}).GetByte("csv", "sep"); !ok || b != ';' {
+		t.Errorf("expected ';', got %q, %v", b, ok)
+	}
+}
+
+func TestGetEnabled(t *testing.T) {
+	for _, c := range []struct {
+		section Section
+		enabled bool
+		ok      bool
+	}{
+		{Section{"enabled": "yes"}, true, true},
+		{Section{"disabled": "true"}, false, true},
+		{Section{"disabled": "off"}, true, true},
+		{Section{"enabled": "true", "disabled": "true"}, true, true},
+		{Section{"enabled": "maybe", "disabled": "1"}, false, true},
+		{Section{"enabled": "maybe"}, false, false},
+		{Section{}, false, false},
+	} {
+		if enabled, ok := c.section.GetEnabled("enabled", "disabled"); enabled != c.enabled || ok != c.ok {
+			t.Errorf("%v: expected %v, %v, got %v, %v", c.section, c.enabled, c.ok, enabled, ok)
+		}
+	}
+}
+
+func TestRequired(t *testing.T) {
+	file := File{"server": {"host": "example.com", "port": "80", "tls": "on", "ratio": "0.5", "timeout": "5s", "empty": "", "bad": "x"}}
+	s := file["server"]
+	if v, err := s.GetStringRequired("host"); err != nil || v != "example.com" {
+		t.Errorf("expected example.com, got %q, %v", v, err)
+	}
+	if v, err := s.GetIntRequired("port"); err != nil || v != 80 {
+		t.Errorf("expected 80, got %v, %v", v, err)
+	}
+	if v, err := s.GetBoolRequired("tls"); err != nil || !v {
+		t.Errorf("expected true, got %v, %v", v, err)
+	}
+	if v, err := s.GetFloatRequired("ratio"); err != nil || v != 0.5 {
+		t.Errorf("expected 0.5, got %v, %v", v, err)
+	}
+	if v, err := s.GetDurationRequired("timeout"); err != nil || v != 5*time.Second {
+		t.Errorf("expected 5s, got %v, %v", v, err)
+	}
+
+	for _, c := range []struct {
+		err    error
+		expect string
+	}{
+		{func() error { _, err := s.GetStringRequired("missing"); return err }(), `missing key "missing"`},
+		{func() error { _, err := s.GetStringRequired("empty"); return err }(), `empty value for required key "empty"`},
+		{func() error { _, err := s.GetIntRequired("empty"); return err }(), `empty value for required key "empty"`},
+		{func() error { _, err := s.GetIntRequired("bad"); return err }(), `invalid int value "x" for key "bad"`},
+		{func() error { _, err := file.GetStringRequired("server", "missing"); return err }(), `section "server": missing key "missing"`},
+		{func() error { _, err := file.GetDurationRequired("db", "timeout"); return err }(), `section "db": missing key "timeout"`},
+	} {
+		if c.err == nil || c.err.Error() != c.expect {
+			t.Errorf("expected %q, got %v", c.expect, c.err)
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The value written in place of redacted keys.
+const redacted = "****"
+
+// WriteOptions controls how a File is serialized.
+type WriteOptions struct {
+	// Keys whose values are written as "****". Matching is case-insensitive and each entry may be a
+	// path.Match pattern such as "*password*". Only the output is affected, never the File itself.
+	RedactKeys []string
+
+	// Trims section names and collapses internal runs of whitespace to a single space on output, so a
+	// section named "my  server" is written as "[my server]". Sections whose names normalize to the
+	// same string are written as separate blocks and merge again when loaded.
+	NormalizeSectionNames bool
+
+	// The separator written between keys and values, which must be "=" optionally surrounded by
+	// whitespace. Empty means " = ".
+	Separator string
+
+	// Omits the blank line normally written between sections.
+	OmitBlankLines bool
+
+	// Wraps values in double quotes, escaping \" and \\, when they would not otherwise survive being
+	// read back with ParseOptions.Quotes and InlineComments: values with leading or trailing whitespace,
+	// a comment marker, an "=" or a leading quote. Other values are written bare.
+	QuoteValues bool
+
+	// Orders sections and keys on output instead of the default alphabetical order, which still breaks
+	// ties. The default section is always written first, since it has no header.
+	SectionLess func(a, b string) bool
+	KeyLess     func(a, b string) bool
+
+	// Returns the blocks of a repeated section to write separately, such as Decoder.SectionList after
+	// decoding with ParseOptions.RepeatedSections. The blocks are only used while merging them still
+	// gives exactly the section's contents; a section edited since is written as a single block, so the
+	// edits are never lost or undone. Nil writes every section as a single block.
+	Blocks func(name string) []Section
+
+	// Writes sections whose only key is RawKey as raw sections, a "[name|raw]" header followed by the
+	// value verbatim, to be read back with ParseOptions.RawSections. A value with a line that would be
+	// read as a section header, such as "[admin]", cannot be written that way and is an error.
+	RawSections bool
+
+	// Escapes "]" as "\]" and backslashes as "\\" in section names, to be read back with
+	// ParseOptions.EscapedSectionNames.
+	EscapeSectionNames bool
+
+	// The line terminator written after each line, one of "\n", "\r\n" or "\r". Empty means "\n". Newlines
+	// inside the value of a raw section are converted as well.
+	LineEnding string
+
+	// Comment lines written at the top of the output, such as a "Generated by" banner, each prefixed
+	// with "# ". A line containing line breaks is written as several comment lines. They are separated
+	// from the first section by a blank line unless OmitBlankLines is set.
+	Header []string
+
+	// Comment lines written before a section, keyed by section name and prefixed with "# ". Comments for
+	// the default section are written before its keys. A repeated section gets them before its first
+	// block only. Lines containing line breaks are split as for Header.
+	SectionComments map[string][]string
+
+	// Makes WriteFileWith hold the advisory lock used by UpdateFile while writing.
+	Lock bool
+
+	// How long WriteFileWith waits for the lock before giving up with ErrLockTimeout. Zero means
+	// DefaultLockTimeout.
+	LockTimeout time.Duration
+}
+
+// The time UpdateFile, and WriteFileWith when locking, wait for another writer to release a file.
+const DefaultLockTimeout = 10 * time.Second
+
+// ErrLockTimeout is returned when the lock on a file could not be acquired in time.
+var ErrLockTimeout = errors.New("timed out waiting for INI file lock")
+
+// Writes the File to w in INI format. Sections and keys are written in sorted order, with the default
+// section first and without a header.
+func (f File) Write(w io.Writer) error {
+	return f.WriteWith(w, WriteOptions{})
+}
+
+// Writes the File to w in INI format using the given options. Nothing is written if a section name, key
+// or value could not be read back as written, such as a key containing "=" or a value with a line break.
+func (f File) WriteWith(w io.Writer, opts WriteOptions) error {
+	for _, pattern := range opts.RedactKeys {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	if opts.Separator == "" {
+		opts.Separator = " = "
+	} else if strings.TrimSpace(opts.Separator) != "=" {
+		return fmt.Errorf("invalid INI separator %q", opts.Separator)
+	}
+	switch opts.LineEnding {
+	case "":
+		opts.LineEnding = "\n"
+	case "\n", "\r\n", "\r":
+	default:
+		return fmt.Errorf("invalid INI line ending %q", opts.LineEnding)
+	}
+	if err := f.checkWritable(opts); err != nil {
+		return err
+	}
+	out := bufio.NewWriter(w)
+	writeComments(out, opts.Header, opts)
+	first := len(opts.Header) == 0
+	names := sortedSections(f)
+	if opts.SectionLess != nil {
+		sort.SliceStable(names, func(i, j int) bool {
+			// The default section has no header and must come first
+			if names[i] == "" || names[j] == "" {
+				return names[i] == ""
+			}
+			return opts.SectionLess(names[i], names[j])
+		})
+	}
+	for _, name := range names {
+		blocks := []Section{f[name]}
+		if name != "" {
+			blocks = f.blocks(name, opts.Blocks)
+		} else if len(f[name]) == 0 {
+			continue
+		}
+		for i, section := range blocks {
+			if !first && !opts.OmitBlankLines {
+				out.WriteString(opts.LineEnding)
+			}
+			first = false
+			if i == 0 {
+				writeComments(out, opts.SectionComments[name], opts)
+			}
+			writeSection(out, name, section, opts)
+		}
+	}
+	return out.Flush()
+}
+
+// Returns an error naming the first section name, key or value that would not be read back as written.
+// Line breaks are rejected since they would split it into separate lines, letting a value inject
+// sections or keys; redacted values are exempt, and so is the value of a section written as a raw
+// section, unless one of its lines looks like a section header. Keys must also be non-empty, contain no
+// "=" and not start with a comment marker or "[", and section names must contain no "=".
+func (f File) checkWritable(opts WriteOptions) error {
+	for _, name := range sortedSections(f) {
+		if strings.ContainsAny(name, "\r\n") {
+			return fmt.Errorf("section name %q contains a line break", name)
+		}
+		if strings.Contains(name, "=") {
+			return fmt.Errorf("invalid INI section name %q", name)
+		}
+		section := f[name]
+		for _, key := range sortedKeys(section) {
+			if strings.ContainsAny(key, "\r\n") {
+				return fmt.Errorf("key %q in section %q contains a line break", key, name)
+			}
+			raw := opts.RawSections && name != "" && key == RawKey && len(section) == 1
+			if !raw && !validKey(key) {
+				return fmt.Errorf("invalid INI key %q in section %q", key, name)
+			}
+			if raw && hasHeaderLine(section[key]) {
+				return fmt.Errorf("raw value of section %q contains a section header line", name)
+			}
+			if !raw && !opts.redact(key) && strings.ContainsAny(section[key], "\r\n") {
+				return fmt.Errorf("value of key %q in section %q contains a line break", key, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Reports whether a key without line breaks is read back as the key of a property: one that is not
+// blank, contains no "=" and does not start with a comment marker or "[".
+func validKey(key string) bool {
+	trimmed := strings.TrimSpace(key)
+	return trimmed != "" && !strings.Contains(key, "=") && !strings.ContainsAny(trimmed[:1], "[;#")
+}
+
+// Reports whether any line of a raw section value would be read back as a section header, ending the raw
+// section early.
+func hasHeaderLine(value string) bool {
+	for _, line := range strings.FieldsFunc(value, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the blocks to write for a named section: those returned by list while they still merge to
+// exactly the section's contents, or else the section as a single block.
+func (f File) blocks(name string, list func(name string) []Section) []Section {
+	if list == nil {
+		return []Section{f[name]}
+	}
+	blocks := list(name)
+	if len(blocks) == 0 {
+		return []Section{f[name]}
+	}
+	merged := make(Section)
+	for _, block := range blocks {
+		merged.Merge(block)
+	}
+	if len(merged) != len(f[name]) {
+		return []Section{f[name]}
+	}
+	for key, value := range merged {
+		if current, ok := f[name][key]; !ok || current != value {
+			return []Section{f[name]}
+		}
+	}
+	return blocks
+}
+
+// Writes each line as a "# " comment, or a bare "#" for an empty line. A line containing line breaks is
+// written as one comment per embedded line, so it cannot end the comment and inject sections or keys.
+func writeComments(out *bufio.Writer, lines []string, opts WriteOptions) {
+	breaks := strings.NewReplacer("\r\n", "\n", "\r", "\n")
+	for _, line := range lines {
+		for _, line := range strings.Split(breaks.Replace(line), "\n") {
+			if line == "" {
+				out.WriteString("#" + opts.LineEnding)
+				continue
+			}
+			out.WriteString("# " + line + opts.LineEnding)
+		}
+	}
+}
+
+func writeSection(out *bufio.Writer, name string, section Section, opts WriteOptions) {
+	if name != "" {
+		if opts.NormalizeSectionNames {
+			name = strings.Join(strings.Fields(name), " ")
+		}
+		if opts.EscapeSectionNames {
+			name = strings.NewReplacer("\\", "\\\\", "]", "\\]").Replace(name)
+		}
+		if raw, ok := section[RawKey]; ok && opts.RawSections && len(section) == 1 {
+			out.WriteString("[" + name + "|raw]" + opts.LineEnding)
+			if raw != "" {
+				out.WriteString(strings.ReplaceAll(raw, "\n", opts.LineEnding) + opts.LineEnding)
+			}
+			return
+		}
+		out.WriteString("[" + name + "]" + opts.LineEnding)
+	}
+	keys := sortedKeys(section)
+	if opts.KeyLess != nil {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return opts.KeyLess(keys[i], keys[j])
+		})
+	}
+	for _, key := range keys {
+		value := section[key]
+		if opts.redact(key) {
+			value = redacted
+		}
+		if opts.QuoteValues && needsQuotes(value) {
+			value = quote(value)
+		}
+		if value == "" {
+			// Avoid trailing whitespace, so "key =" rather than "key = "
+			out.WriteString(key + strings.TrimRight(opts.Separator, " \t") + opts.LineEnding)
+			continue
+		}
+		out.WriteString(key + opts.Separator + value + opts.LineEnding)
+	}
+}
+
+// Writes only the sections and keys of the File that are missing from base or have a different value
+// there, producing an overlay that recreates the File when merged over base. Keys and sections
+// removed relative to base are omitted, since an overlay cannot express deletions.
+func (f File) WritePatch(w io.Writer, base File) error {
+	patch := make(File)
+	for name, section := range f {
+		baseSection, ok := base[name]
+		if !ok {
+			patch.Section(name)
+		}
+		for key, value := range section {
+			if baseValue, ok := baseSection[key]; !ok || baseValue != value {
+				patch.Section(name)[key] = value
+			}
+		}
+	}
+	return patch.Write(w)
+}
+
+// Writes the File to the named file. The data is written to a temporary file in the same directory
+// which is then renamed over filename, so readers never observe a partially written file. An existing
+// file keeps its permissions; a new file is created with mode 0644.
+func (f File) WriteFile(filename string) error {
+	return f.WriteFileWith(filename, WriteOptions{})
+}
+
+// Writes the File to the named file atomically, as with WriteFile, using the given options.
+func (f File) WriteFileWith(filename string, opts WriteOptions) error {
+	if opts.Lock {
+		timeout := opts.LockTimeout
+		if timeout <= 0 {
+			timeout = DefaultLockTimeout
+		}
+		unlock, err := lockFile(filename, timeout)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	return writeFileAtomic(filename, f, opts)
+}
+
+// Loads the named file, applies fn to it and writes the result back atomically, all while holding an
+// advisory lock so that concurrent read-modify-write sequences do not lose each other's updates. A
+// missing file is treated as empty. If fn returns an error the file is left untouched.
+//
+// The lock is a sidecar file named filename+".lock", created exclusively, which works the same on every
+// platform. It is advisory: only writers going through UpdateFile or a locking WriteFileWith honor it.
+// UpdateFile waits up to DefaultLockTimeout for the lock and then returns ErrLockTimeout. A lock file
+// left behind by a crashed process is not removed automatically and must be deleted by hand.
+func UpdateFile(filename string, fn func(File) error) error {
+	unlock, err := lockFile(filename, DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := LoadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err = fn(file); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, file, WriteOptions{})
+}
+
+// Acquires the sidecar lock for filename, polling until timeout. The returned function releases it.
+func lockFile(filename string, timeout time.Duration) (unlock func(), err error) {
+	lockname := filename + ".lock"
+	deadline := time.Now().Add(timeout)
+	for {
+		lock, err := os.OpenFile(lockname, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			lock.Close()
+			return func() { os.Remove(lockname) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func writeFileAtomic(filename string, f File, opts WriteOptions) (err error) {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err = f.WriteWith(tmp, opts); err != nil {
+		return
+	}
+	if err = tmp.Sync(); err != nil {
+		return
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Returns a SHA-256 hex digest of the File's sections, keys and values, taken over MarshalCanonical, so
+// Files with the same contents always produce the same fingerprint regardless of map iteration order.
+// Empty named sections are part of the content. Files that MarshalCanonical rejects are hashed field by
+// field instead, with tagged, length-prefixed fields.
+func (f File) Fingerprint() string {
+	h := sha256.New()
+	if data, err := f.MarshalCanonical(); err == nil {
+		h.Write([]byte{'c'})
+		h.Write(data)
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	h.Write([]byte{'t'})
+	field := func(tag byte, s string) {
+		fmt.Fprintf(h, "%c%d:%s", tag, len(s), s)
+	}
+	for _, name := range sortedSections(f) {
+		field('s', name)
+		section := f[name]
+		for _, key := range sortedKeys(section) {
+			field('k', key)
+			field('v', section[key])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Returns a canonical serialization of the File, byte-identical for Files with the same contents, for
+// embedding in reproducible artifacts and for hashing. The rules are:
+//
+//   - the keys of the default section come first, without a header, and an empty default section is
+//     not represented at all;
+//   - named sections follow in sorted order, each as a "[name]" line followed by its keys, with repeated
+//     section blocks merged;
+//   - keys are sorted and written as "key=value", with no whitespace around "=" and values verbatim;
+//   - every line ends in "\n", and there are no blank lines or comments.
+//
+// An error is returned for Files whose output would be ambiguous or read back as different entries: when
+// a section name, key or value contains a line break, a section name contains "=", or a key is blank,
+// contains "=" or begins with "[", ";" or "#". Any other File reads back from the output with
+// ParseOptions.CaseSensitive to the same contents, except that whitespace around section names, keys
+// and values is trimmed as usual.
+func (f File) MarshalCanonical() ([]byte, error) {
+	var buf bytes.Buffer
+	for _, name := range sortedSections(f) {
+		if strings.ContainsAny(name, "\r\n") {
+			return nil, fmt.Errorf("section name %q contains a line break", name)
+		}
+		if strings.Contains(name, "=") {
+			return nil, fmt.Errorf("section name %q cannot be written canonically", name)
+		}
+		if name != "" {
+			buf.WriteString("[" + name + "]\n")
+		}
+		section := f[name]
+		for _, key := range sortedKeys(section) {
+			value := section[key]
+			if strings.ContainsAny(key, "\r\n") || !validKey(key) {
+				return nil, fmt.Errorf("key %q in section %q cannot be written canonically", key, name)
+			}
+			if strings.ContainsAny(value, "\r\n") {
+				return nil, fmt.Errorf("value of key %q in section %q contains a line break", key, name)
+			}
+			buf.WriteString(key + "=" + value + "\n")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Reports whether a value must be quoted to be read back unchanged.
+func needsQuotes(value string) bool {
+	return value != strings.TrimSpace(value) || strings.ContainsAny(value, ";#=") ||
+		strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "'")
+}
+
+// Wraps a value in double quotes, escaping quotes and backslashes.
+func quote(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	return "\"" + strings.ReplaceAll(value, "\"", "\\\"") + "\""
+}
+
+// Reports whether the value of key should be masked on output.
+func (opts WriteOptions) redact(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range opts.RedactKeys {
+		if ok, _ := path.Match(strings.ToLower(pattern), key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the section names of a File in sorted order.
+func sortedSections(f File) []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the keys of a Section in sorted order.
+func sortedKeys(s Section) []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,412 @@
+package ini
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	file := File{
+		"":    {"herp": "derp"},
+		"foo": {"hello": "world", "multiple": "equals = signs"},
+		"bar": {},
+	}
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expect := "herp = derp\n\n[bar]\n\n[foo]\nhello = world\nmultiple = equals = signs\n"
+	if buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+	reloaded, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reloaded, file) {
+		t.Errorf("expected %v, got %v", file, reloaded)
+	}
+}
+
+func TestWriteRedactKeys(t *testing.T) {
+	file := File{
+		"db": {"user": "admin", "password": "hunter2", "admin_password_old": "x", "token": "abc"},
+	}
+	var buf bytes.Buffer
+	err := file.WriteWith(&buf, WriteOptions{RedactKeys: []string{"*PASSWORD*", "Token"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "[db]\nadmin_password_old = ****\npassword = ****\ntoken = ****\nuser = admin\n"
+	if buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+	if value, _ := file.Get("db", "password"); value != "hunter2" {
+		t.Error("redaction modified the File")
+	}
+	if err := file.WriteWith(&buf, WriteOptions{RedactKeys: []string{"["}}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestWriteNormalizeSectionNames(t *testing.T) {
+	file := File{"  my  \tserver ": {"host": "example.com"}}
+	opts := WriteOptions{NormalizeSectionNames: true}
+	var first bytes.Buffer
+	if err := file.WriteWith(&first, opts); err != nil {
+		t.Fatal(err)
+	}
+	expect := "[my server]\nhost = example.com\n"
+	if first.String() != expect {
+		t.Errorf("expected %q, got %q", expect, first.String())
+	}
+	reloaded, err := Load(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var second bytes.Buffer
+	if err := reloaded.WriteWith(&second, opts); err != nil {
+		t.Fatal(err)
+	}
+	if second.String() != first.String() {
+		t.Errorf("normalization not stable: %q then %q", first.String(), second.String())
+	}
+}
+
+func TestUpdateFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "shared.ini")
+	if err := (File{"counter": {"n": "0"}}).WriteFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := UpdateFile(filename, func(f File) error {
+				n, _ := strconv.Atoi(f.Section("counter")["n"])
+				f.Section("counter")["n"] = strconv.Itoa(n + 1)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	file, err := LoadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := file.Get("counter", "n"); value != "10" {
+		t.Errorf("expected 10 serialized updates, got %q", value)
+	}
+	if _, err := os.Stat(filename + ".lock"); !os.IsNotExist(err) {
+		t.Error("lock file not removed")
+	}
+}
+
+func TestWriteFileLockTimeout(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "locked.ini")
+	if err := os.WriteFile(filename+".lock", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := File{}.WriteFileWith(filename, WriteOptions{Lock: true, LockTimeout: 20 * time.Millisecond})
+	if err != ErrLockTimeout {
+		t.Errorf("expected ErrLockTimeout, got %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := File{"": {"x": "1"}, "foo": {"a": "1", "b": "2"}, "bar": {}}
+	b := File{"bar": {}, "foo": {"b": "2", "a": "1"}, "": {"x": "1"}}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("expected equal Files to have equal fingerprints")
+	}
+	if len(a.Fingerprint()) != 64 {
+		t.Errorf("expected a SHA-256 hex digest, got %q", a.Fingerprint())
+	}
+	for _, c := range []File{
+		{"": {"x": "1"}, "foo": {"a": "1", "b": "3"}, "bar": {}},
+		{"": {"x": "1"}, "foo": {"a": "1", "b": "2"}},
+		{"": {"x": "1"}, "foo": {"a": "1b", "": "2"}, "bar": {}},
+	} {
+		if c.Fingerprint() == a.Fingerprint() {
+			t.Errorf("expected %v to have a different fingerprint", c)
+		}
+	}
+}
+
+func TestWritePatch(t *testing.T) {
+	base := File{
+		"":     {"name": "app"},
+		"db":   {"host": "localhost", "port": "5432"},
+		"gone": {"x": "1"},
+	}
+	file := File{
+		"":    {"name": "app"},
+		"db":  {"host": "db.example.com", "port": "5432", "pool": "10"},
+		"new": {},
+	}
+	var buf bytes.Buffer
+	if err := file.WritePatch(&buf, base); err != nil {
+		t.Fatal(err)
+	}
+	expect := "[db]\nhost = db.example.com\npool = 10\n\n[new]\n"
+	if buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+	patch, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base.Merge(patch)
+	if value, _ := base.Get("db", "host"); value != "db.example.com" {
+		t.Errorf("expected the patch to override host, got %q", value)
+	}
+}
+
+func TestEmptyValues(t *testing.T) {
+	file, err := Load(strings.NewReader("[a]\nempty =\nbare=\nspaced =   \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := File{"a": {"empty": "", "bare": "", "spaced": ""}}
+	if !reflect.DeepEqual(file, expect) {
+		t.Fatalf("expected %v, got %v", expect, file)
+	}
+	for sep, out := range map[string]string{"": "[a]\nbare =\nempty =\nspaced =\n", "=": "[a]\nbare=\nempty=\nspaced=\n"} {
+		var buf bytes.Buffer
+		if err := file.WriteWith(&buf, WriteOptions{Separator: sep}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != out {
+			t.Errorf("separator %q: expected %q, got %q", sep, out, buf.String())
+		}
+		reloaded, err := Load(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(reloaded, expect) {
+			t.Errorf("separator %q: expected %v, got %v", sep, expect, reloaded)
+		}
+	}
+}
+
+func TestQuoteValuesRoundTrip(t *testing.T) {
+	file := File{"a": {
+		"padded":  "  spaced out  ",
+		"comment": "value ; not a comment # either",
+		"equals":  "user=foo",
+		"quoted":  `"already quoted"`,
+		"single":  "'single'",
+		"escapes": `back\slash "and" quotes\`,
+		"plain":   "bare value",
+		"empty":   "",
+	}}
+	var buf bytes.Buffer
+	if err := file.WriteWith(&buf, WriteOptions{QuoteValues: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "plain = bare value\n") || !strings.Contains(buf.String(), `padded = "  spaced out  "`) {
+		t.Errorf("unexpected quoting in %q", buf.String())
+	}
+	reloaded, err := LoadWith(&buf, ParseOptions{Quotes: true, InlineComments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reloaded, file) {
+		t.Errorf("expected %v, got %v", file, reloaded)
+	}
+}
+
+func TestWriteOrder(t *testing.T) {
+	file := File{"": {"z": "1"}, "alpha": {"b": "1", "a": "2"}, "general": {"x": "1"}, "beta": {}}
+	pinned := func(a, b string) bool {
+		return a == "general" && b != "general"
+	}
+	var buf bytes.Buffer
+	err := file.WriteWith(&buf, WriteOptions{
+		SectionLess:    pinned,
+		KeyLess:        func(a, b string) bool { return a > b },
+		OmitBlankLines: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "z = 1\n[general]\nx = 1\n[alpha]\nb = 1\na = 2\n[beta]\n"
+	if buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	file := File{"": {"name": "app"}, "db": {"host": "localhost", "empty": ""}, "script": {RawKey: "a = 1\nb"}}
+	for _, ending := range []string{"\n", "\r\n", "\r"} {
+		var buf bytes.Buffer
+		if err := file.WriteWith(&buf, WriteOptions{LineEnding: ending, RawSections: true}); err != nil {
+			t.Fatal(err)
+		}
+		expect := strings.ReplaceAll("name = app\n\n[db]\nempty =\nhost = localhost\n\n[script|raw]\na = 1\nb\n", "\n", ending)
+		if buf.String() != expect {
+			t.Errorf("%q: expected %q, got %q", ending, expect, buf.String())
+		}
+		loaded, err := LoadWith(&buf, ParseOptions{RawSections: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(loaded, file) {
+			t.Errorf("%q: expected %v, got %v", ending, file, loaded)
+		}
+	}
+	if err := file.WriteWith(&bytes.Buffer{}, WriteOptions{LineEnding: "\n\n"}); err == nil {
+		t.Error("expected an error for an invalid line ending")
+	}
+}
+
+func TestWriteComments(t *testing.T) {
+	file := File{"": {"name": "app"}, "db": {"host": "localhost"}, "cache": {}}
+	var buf bytes.Buffer
+	err := file.WriteWith(&buf, WriteOptions{
+		Header: []string{"Generated by mytool", "", "Do not edit"},
+		SectionComments: map[string][]string{
+			"":   {"Global settings"},
+			"db": {"Database connection"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "# Generated by mytool\n#\n# Do not edit\n\n# Global settings\nname = app\n\n[cache]\n\n# Database connection\n[db]\nhost = localhost\n"
+	if buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, file) {
+		t.Errorf("expected %v, got %v", file, loaded)
+	}
+}
+
+func TestWriteCommentLineBreaks(t *testing.T) {
+	file := File{"db": {"host": "localhost"}}
+	var buf bytes.Buffer
+	err := file.WriteWith(&buf, WriteOptions{
+		Header:          []string{"banner\n[admin]\nrole = root"},
+		SectionComments: map[string][]string{"db": {"one\r\n\rtwo"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "# banner\n# [admin]\n# role = root\n\n# one\n#\n# two\n[db]\nhost = localhost\n"
+	if buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+	if loaded, err := Load(&buf); err != nil || !reflect.DeepEqual(loaded, file) {
+		t.Errorf("expected %v, got %v, %v", file, loaded, err)
+	}
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	file := File{"": {"z": "1", "a": " padded "}, "db": {"port": "5432", "host": "localhost", "empty": ""}, "bare": {}}
+	data, err := file.MarshalCanonical()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "a= padded \nz=1\n[bare]\n[db]\nempty=\nhost=localhost\nport=5432\n"
+	if string(data) != expect {
+		t.Errorf("expected %q, got %q", expect, data)
+	}
+	loaded, err := LoadWith(bytes.NewReader(data), ParseOptions{CaseSensitive: true})
+	file[""]["a"] = "padded"
+	if err != nil || !reflect.DeepEqual(loaded, file) {
+		t.Errorf("expected %v, got %v, %v", file, loaded, err)
+	}
+	for _, bad := range []File{
+		{"": {"a": "line\nbreak"}},
+		{"": {"a=b": "1"}},
+		{"": {"[a": "b]"}},
+		{"": {";k": "v"}},
+		{"": {" #k": "v"}},
+		{"": {"": "v"}},
+		{"a=b": {}},
+		{"multi\nline": {}},
+	} {
+		if _, err := bad.MarshalCanonical(); err == nil {
+			t.Errorf("%v: expected an error", bad)
+		}
+		if len(bad.Fingerprint()) != 64 {
+			t.Errorf("%v: expected a fingerprint", bad)
+		}
+	}
+}
+
+func TestWriteLineBreaks(t *testing.T) {
+	for _, c := range []struct {
+		file   File
+		expect string
+	}{
+		{File{"user": {"name": "bob\n[admin]\nrole = root"}}, `value of key "name" in section "user" contains a line break`},
+		{File{"": {"a\rb": "1"}}, `key "a\rb" in section "" contains a line break`},
+		{File{"a\nb": {}}, `section name "a\nb" contains a line break`},
+	} {
+		var buf bytes.Buffer
+		if err := c.file.Write(&buf); err == nil || err.Error() != c.expect {
+			t.Errorf("expected %q, got %v", c.expect, err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("expected no output, got %q", buf.String())
+		}
+	}
+
+	file := File{"cert": {RawKey: "line 1\nline 2"}, "user": {"password": "a\nb"}}
+	if err := file.WriteWith(&bytes.Buffer{}, WriteOptions{RawSections: true, RedactKeys: []string{"password"}}); err != nil {
+		t.Errorf("expected raw sections and redacted values to be written, got %v", err)
+	}
+}
+
+func TestWriteInvalidNames(t *testing.T) {
+	for _, c := range []struct {
+		file   File
+		expect string
+	}{
+		{File{"": {"": "x"}}, `invalid INI key "" in section ""`},
+		{File{"a": {"  ": "x"}}, `invalid INI key "  " in section "a"`},
+		{File{"a": {"k=v": "x"}}, `invalid INI key "k=v" in section "a"`},
+		{File{"a": {"; note": "x"}}, `invalid INI key "; note" in section "a"`},
+		{File{"a": {"#x": "x"}}, `invalid INI key "#x" in section "a"`},
+		{File{"a": {" [b]": "x"}}, `invalid INI key " [b]" in section "a"`},
+		{File{"a=b": {"k": "v"}}, `invalid INI section name "a=b"`},
+	} {
+		var buf bytes.Buffer
+		if err := c.file.Write(&buf); err == nil || err.Error() != c.expect {
+			t.Errorf("expected %q, got %v", c.expect, err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("expected no output, got %q", buf.String())
+		}
+	}
+}
+
+func TestWriteRawHeaderLine(t *testing.T) {
+	for _, raw := range []string{"line1\n[admin]\npassword = x", "line1\r  [admin]  "} {
+		file := File{"cert": {RawKey: raw}}
+		err := file.WriteWith(&bytes.Buffer{}, WriteOptions{RawSections: true})
+		if err == nil || !strings.Contains(err.Error(), `"cert"`) {
+			t.Errorf("%q: expected an error naming the section, got %v", raw, err)
+		}
+	}
+	file := File{"cert": {RawKey: "-----BEGIN-----\n[not a header\n-----END-----"}}
+	if err := file.WriteWith(&bytes.Buffer{}, WriteOptions{RawSections: true}); err != nil {
+		t.Errorf("expected other lines to be written, got %v", err)
+	}
+}